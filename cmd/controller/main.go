@@ -20,9 +20,11 @@ import (
 	"k8s.io/client-go/tools/leaderelection/resourcelock"
 	"k8s.io/klog"
 
+	nats "github.com/nats-io/nats.go"
 	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
 	"github.com/refunc/refunc/pkg/controllers/funcinst"
 	"github.com/refunc/refunc/pkg/controllers/xenv"
+	"github.com/refunc/refunc/pkg/env"
 	"github.com/refunc/refunc/pkg/utils/cmdutil"
 	"github.com/refunc/refunc/pkg/utils/cmdutil/sharedcfg"
 	"github.com/refunc/refunc/pkg/utils/k8sutil"
@@ -40,6 +42,9 @@ const (
 
 	// DefaultIdleDuraion is default value of lifetime for a refunc
 	DefaultIdleDuraion = 3 * DefaultGCPeriod
+
+	// DefaultScaledToZeroTTL is default idle duration before a refunc scaled to zero is collected
+	DefaultScaledToZeroTTL = 24 * time.Hour
 )
 
 // NewCmd creates new commands
@@ -49,6 +54,11 @@ func NewCmd() *cobra.Command {
 		IdleDuraion time.Duration
 		Workers     int
 		Namespace   string
+
+		ScaleByConcurrency bool
+
+		ScaleToZero     bool
+		ScaledToZeroTTL time.Duration
 	}
 
 	cmd := &cobra.Command{
@@ -77,6 +87,16 @@ func NewCmd() *cobra.Command {
 				config.IdleDuraion = DefaultIdleDuraion
 			}
 
+			// nats is optional, it's used to scale funcinsts by in-flight requests
+			var natsConn *nats.Conn
+			if config.ScaleByConcurrency {
+				natsConn, err = env.NewNatsConn(nats.Name(namespace + "/" + name))
+				if err != nil {
+					klog.Fatalf("Failed to connect to nats %s, %v", env.GlobalNatsURLString(), err)
+				}
+				defer natsConn.Close()
+			}
+
 			ctx, cancel := context.WithCancel(context.Background())
 
 			sc := sharedcfg.New(ctx, config.Namespace)
@@ -100,6 +120,9 @@ func NewCmd() *cobra.Command {
 				}
 				fnic.GCInterval = config.GCInterval
 				fnic.IdleDuraion = config.IdleDuraion
+				fnic.ScaleToZero = config.ScaleToZero
+				fnic.ScaledToZeroTTL = config.ScaledToZeroTTL
+				fnic.NatsConn = natsConn
 				return sharedcfg.RunnerFunc(func(stopC <-chan struct{}) {
 					fnic.Run(config.Workers, stopC)
 				})
@@ -184,6 +207,9 @@ func NewCmd() *cobra.Command {
 	cmd.Flags().DurationVar(&config.IdleDuraion, "idle-duration", DefaultIdleDuraion, "The lifetime for a active refunc")
 	cmd.Flags().IntVar(&config.Workers, "workers", runtime.NumCPU(), "The number of workers")
 	cmd.Flags().StringVarP(&config.Namespace, "namespace", "n", "", "The scope of namepsace to manipulate")
+	cmd.Flags().BoolVar(&config.ScaleByConcurrency, "scale-by-concurrency", false, "Enable scaling funcinsts by in-flight requests, requires nats")
+	cmd.Flags().BoolVar(&config.ScaleToZero, "scale-to-zero", true, "Scale idle funcinsts to zero instead of collecting them")
+	cmd.Flags().DurationVar(&config.ScaledToZeroTTL, "scaled-to-zero-ttl", DefaultScaledToZeroTTL, "The idle duration before a funcinst scaled to zero is collected, 0 keeps it")
 	return cmd
 }
 
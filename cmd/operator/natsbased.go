@@ -21,6 +21,7 @@ const defaultTappingInterval = 30 * time.Second
 func cmdNatsBased() *cobra.Command {
 	var config struct {
 		TappingInterval time.Duration
+		Pending         natsbased.Config
 	}
 
 	cmd := operatorCmdTemplate(func(cfg sharedcfg.Configs) sharedcfg.Runner {
@@ -42,7 +43,7 @@ func cmdNatsBased() *cobra.Command {
 			cfg.RestConfig(),
 			cfg.RefuncClient(),
 			cfg.RefuncInformers(),
-			natsbased.NewHandler(natsConn),
+			natsbased.NewHandler(natsConn, config.Pending),
 			credsyncer.NewGeneratedProvider(24*time.Hour),
 		)
 		if err != nil {
@@ -62,6 +63,8 @@ func cmdNatsBased() *cobra.Command {
 	cmd.Long = cmd.Short
 
 	cmd.Flags().DurationVar(&config.TappingInterval, "tapping-interval", defaultTappingInterval, "The interval bewteen each tapping (should at max half of refunc's lifetime)")
+	cmd.Flags().IntVar(&config.Pending.MaxPending, "max-pending", natsbased.DefaultMaxPending, "The max number of requests waiting for a funcinst to be online")
+	cmd.Flags().DurationVar(&config.Pending.MaxPendingWait, "max-pending-wait", 0, "The max duration a request waits for a funcinst to be online, 0 means until the request is timed out")
 
 	return cmd
 }
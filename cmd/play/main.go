@@ -17,8 +17,11 @@ import (
 	"github.com/refunc/refunc/pkg/credsyncer"
 	"github.com/refunc/refunc/pkg/env"
 	"github.com/refunc/refunc/pkg/operators/funcinsts"
+	"github.com/refunc/refunc/pkg/operators/triggers/commontrigger"
 	"github.com/refunc/refunc/pkg/operators/triggers/crontrigger"
+	"github.com/refunc/refunc/pkg/operators/triggers/eventtrigger"
 	"github.com/refunc/refunc/pkg/operators/triggers/httptrigger"
+	"github.com/refunc/refunc/pkg/operators/triggers/streamtrigger"
 	"github.com/refunc/refunc/pkg/transport/natsbased"
 	"github.com/refunc/refunc/pkg/utils/cmdutil"
 	"github.com/refunc/refunc/pkg/utils/cmdutil/pflagenv/wrapcobra"
@@ -42,6 +45,9 @@ const (
 
 	// DefaultIdleDuraion is default value of lifetime for a refunc
 	DefaultIdleDuraion = 3 * DefaultGCPeriod
+
+	// DefaultScaledToZeroTTL is default idle duration before a refunc scaled to zero is collected
+	DefaultScaledToZeroTTL = 24 * time.Hour
 )
 
 var config struct {
@@ -137,6 +143,9 @@ func startCmd() *cobra.Command {
 				}
 				fnic.GCInterval = DefaultGCPeriod
 				fnic.IdleDuraion = DefaultIdleDuraion
+				fnic.ScaleToZero = true
+				fnic.ScaledToZeroTTL = DefaultScaledToZeroTTL
+				fnic.NatsConn = natsConn
 				return sharedcfg.RunnerFunc(func(stopC <-chan struct{}) {
 					fnic.Run(1, stopC)
 				})
@@ -166,7 +175,7 @@ func startCmd() *cobra.Command {
 					cfg.RestConfig(),
 					cfg.RefuncClient(),
 					cfg.RefuncInformers(),
-					natsbased.NewHandler(natsConn),
+					natsbased.NewHandler(natsConn, natsbased.Config{}),
 					credsyncer.NewSimpleProvider(),
 				)
 				if err != nil {
@@ -183,6 +192,49 @@ func startCmd() *cobra.Command {
 					cfg.RestConfig(),
 					cfg.RefuncClient(),
 					cfg.RefuncInformers(),
+					cfg.KubeInformers(),
+				)
+				if err != nil {
+					klog.Fatalf("Failed to create trigger, %v", err)
+				}
+
+				return r
+			})
+
+			sc.AddController(func(cfg sharedcfg.Configs) sharedcfg.Runner {
+				r, err := eventtrigger.NewOperator(
+					cfg.Context(),
+					cfg.RestConfig(),
+					cfg.RefuncClient(),
+					cfg.RefuncInformers(),
+				)
+				if err != nil {
+					klog.Fatalf("Failed to create trigger, %v", err)
+				}
+
+				return r
+			})
+
+			sc.AddController(func(cfg sharedcfg.Configs) sharedcfg.Runner {
+				r, err := commontrigger.NewOperator(
+					cfg.Context(),
+					cfg.RestConfig(),
+					cfg.RefuncClient(),
+					cfg.RefuncInformers(),
+				)
+				if err != nil {
+					klog.Fatalf("Failed to create trigger, %v", err)
+				}
+
+				return r
+			})
+
+			sc.AddController(func(cfg sharedcfg.Configs) sharedcfg.Runner {
+				r, err := streamtrigger.NewOperator(
+					cfg.Context(),
+					cfg.RestConfig(),
+					cfg.RefuncClient(),
+					cfg.RefuncInformers(),
 				)
 				if err != nil {
 					klog.Fatalf("Failed to create trigger, %v", err)
@@ -197,6 +249,7 @@ func startCmd() *cobra.Command {
 					cfg.RestConfig(),
 					cfg.RefuncClient(),
 					cfg.RefuncInformers(),
+					cfg.KubeInformers(),
 				)
 				if err != nil {
 					klog.Fatalf("Failed to create trigger, %v", err)
@@ -55,6 +55,11 @@ data:
 
     write_deadline: "2s"
 
+    # used by stream triggers
+    jetstream {
+        store_dir: "/data/jetstream"
+    }
+
   nginx.conf: |
     server {
         listen 80;
@@ -166,6 +171,27 @@ spec:
     - name: v1beta3
       served: true
       storage: true
+      additionalPrinterColumns:
+        - jsonPath: .spec.funcName
+          name: Func
+          type: string
+        - jsonPath: .spec.type
+          name: Type
+          type: string
+        - jsonPath: .status.lastScheduleTime
+          name: Last Schedule
+          type: date
+        - jsonPath: .status.lastSuccessfulTime
+          name: Last Success
+          type: date
+        - jsonPath: .status.nextScheduleTime
+          name: Next Schedule
+          type: string
+        - jsonPath: .metadata.creationTimestamp
+          name: Age
+          type: date
+      subresources:
+        status: {}
       schema:
         openAPIV3Schema:
           type: object
@@ -439,6 +465,8 @@ spec:
         volumeMounts:
         - name: config-volume
           mountPath: /etc/nats/config
+        - name: data
+          mountPath: /data
         ports:
         - containerPort: 4222
           name: client
@@ -459,6 +487,8 @@ spec:
           items:
           - key: nats.conf
             path: nats.conf
+      - name: data
+        emptyDir: {}
 
 ---
 
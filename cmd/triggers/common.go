@@ -0,0 +1,38 @@
+package triggers
+
+import (
+	"k8s.io/klog"
+
+	"github.com/refunc/refunc/pkg/operators/triggers/commontrigger"
+	"github.com/refunc/refunc/pkg/utils/cmdutil/sharedcfg"
+	"github.com/spf13/cobra"
+)
+
+func cmdCommonTrigger() *cobra.Command {
+	var config struct {
+		MaxInflight int
+	}
+
+	cmd := triggerCmdTemplate(func(sc sharedcfg.SharedConfigs) {
+		sc.AddController(func(cfg sharedcfg.Configs) sharedcfg.Runner {
+			r, err := commontrigger.NewOperator(
+				cfg.Context(),
+				cfg.RestConfig(),
+				cfg.RefuncClient(),
+				cfg.RefuncInformers(),
+			)
+			if err != nil {
+				klog.Fatalf("Failed to create trigger, %v", err)
+			}
+			r.MaxInflight = config.MaxInflight
+			return r
+		})
+	})
+
+	cmd.Use = "common"
+	cmd.Short = "operator for common trigger"
+	cmd.Long = cmd.Short
+	cmd.Flags().IntVar(&config.MaxInflight, "max-inflight", commontrigger.DefaultMaxInflight, "Max number of concurrent invocations")
+
+	return cmd
+}
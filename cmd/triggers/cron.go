@@ -1,14 +1,24 @@
 package triggers
 
 import (
+	"context"
+	"os"
+	"time"
+
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
 	"k8s.io/klog"
 
 	"github.com/refunc/refunc/pkg/operators/triggers/crontrigger"
 	"github.com/refunc/refunc/pkg/utils/cmdutil/sharedcfg"
+	"github.com/refunc/refunc/pkg/utils/k8sutil"
 	"github.com/spf13/cobra"
 )
 
 func cmdCronTrigger() *cobra.Command {
+	var config struct {
+		LeaderElect bool
+	}
 
 	cmd := triggerCmdTemplate(func(sc sharedcfg.SharedConfigs) {
 		sc.AddController(func(cfg sharedcfg.Configs) sharedcfg.Runner {
@@ -17,11 +27,16 @@ func cmdCronTrigger() *cobra.Command {
 				cfg.RestConfig(),
 				cfg.RefuncClient(),
 				cfg.RefuncInformers(),
+				cfg.KubeInformers(),
 			)
 			if err != nil {
 				klog.Fatalf("Failed to create trigger, %v", err)
 			}
 
+			if config.LeaderElect {
+				// only the leader fires schedules
+				return leaderElectedRunner(cfg, "refunc-crontrigger", r)
+			}
 			return r
 		})
 	})
@@ -29,6 +44,63 @@ func cmdCronTrigger() *cobra.Command {
 	cmd.Use = "cron"
 	cmd.Short = "operator for cron trigger"
 	cmd.Long = cmd.Short
+	cmd.Flags().BoolVar(&config.LeaderElect, "leader-elect", true, "Elect a leader among replicas by a lease, only the leader schedules tasks")
 
 	return cmd
 }
+
+// leaderElectedRunner returns a runner that runs r once it becomes the leader,
+// the process exits when leadership is lost so that it can rejoin the election.
+func leaderElectedRunner(cfg sharedcfg.Configs, lockName string, r sharedcfg.Runner) sharedcfg.Runner {
+	namespace, id := os.Getenv(EnvMyPodNamespace), os.Getenv(EnvMyPodName)
+
+	rl, err := resourcelock.New(
+		resourcelock.LeasesResourceLock,
+		namespace,
+		lockName,
+		cfg.KubeClient().CoreV1(),
+		cfg.KubeClient().CoordinationV1(),
+		resourcelock.ResourceLockConfig{
+			Identity:      id,
+			EventRecorder: k8sutil.CreateRecorder(cfg.KubeClient(), id, namespace),
+		},
+	)
+	if err != nil {
+		klog.Fatalf("Fail to create lock, %v", err)
+	}
+
+	return sharedcfg.RunnerFunc(func(stopC <-chan struct{}) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			<-stopC
+			cancel()
+		}()
+
+		leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+			Lock:            rl,
+			LeaseDuration:   15 * time.Second,
+			RenewDeadline:   10 * time.Second,
+			RetryPeriod:     2 * time.Second,
+			ReleaseOnCancel: true,
+			Callbacks: leaderelection.LeaderCallbacks{
+				OnStartedLeading: func(ctx context.Context) {
+					klog.Infof("Start leading %s", lockName)
+					r.Run(ctx.Done())
+				},
+				OnStoppedLeading: func() {
+					select {
+					case <-stopC:
+						klog.Info("Stop leading, exiting")
+					default:
+						klog.Errorf("Lost leadership of %s, exit", lockName)
+						os.Exit(1)
+					}
+				},
+				OnNewLeader: func(identity string) {
+					klog.Infof("New leader %q detected", identity)
+				},
+			},
+		})
+	})
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/refunc/refunc/pkg/operators/triggers/httptrigger"
 	"github.com/refunc/refunc/pkg/utils/cmdutil/sharedcfg"
+	"github.com/refunc/refunc/pkg/utils/k8sutil"
 	"github.com/spf13/cobra"
 )
 
@@ -19,6 +20,7 @@ func cmdRPCTrigger() *cobra.Command {
 			MaxAge           int
 			AllowCredentials bool
 		}
+		TrustedProxies []string
 	}
 
 	cmd := triggerCmdTemplate(func(sc sharedcfg.SharedConfigs) {
@@ -28,6 +30,7 @@ func cmdRPCTrigger() *cobra.Command {
 				cfg.RestConfig(),
 				cfg.RefuncClient(),
 				cfg.RefuncInformers(),
+				cfg.KubeInformers(),
 			)
 			if err != nil {
 				klog.Fatalf("Failed to create trigger, %v", err)
@@ -35,6 +38,8 @@ func cmdRPCTrigger() *cobra.Command {
 
 			// config
 			r.CORS = config.CORS
+			r.TrustedProxies = config.TrustedProxies
+			r.EventRecorder = k8sutil.CreateRecorder(cfg.KubeClient(), httptrigger.Type, cfg.Namespace())
 
 			return r
 		})
@@ -52,5 +57,7 @@ func cmdRPCTrigger() *cobra.Command {
 	cmd.Flags().BoolVar(&config.CORS.AllowCredentials, "cors-allow-credentials", false, "CORS config if allow credentials")
 	cmd.Flags().IntVar(&config.CORS.MaxAge, "cors-max-age", 0, "CORS config for max age")
 
+	cmd.Flags().StringSliceVar(&config.TrustedProxies, "trusted-proxies", []string{}, "CIDRs of proxies whose X-Forwarded-For is trusted by rate limits")
+
 	return cmd
 }
@@ -34,6 +34,9 @@ func NewCmd() *cobra.Command {
 	}
 	cmd.AddCommand(wrapcobra.Wrap(cmdRPCTrigger()))
 	cmd.AddCommand(wrapcobra.Wrap(cmdCronTrigger()))
+	cmd.AddCommand(wrapcobra.Wrap(cmdEventTrigger()))
+	cmd.AddCommand(wrapcobra.Wrap(cmdCommonTrigger()))
+	cmd.AddCommand(wrapcobra.Wrap(cmdStreamTrigger()))
 	return cmd
 }
 
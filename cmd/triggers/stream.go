@@ -0,0 +1,32 @@
+package triggers
+
+import (
+	"k8s.io/klog"
+
+	"github.com/refunc/refunc/pkg/operators/triggers/streamtrigger"
+	"github.com/refunc/refunc/pkg/utils/cmdutil/sharedcfg"
+	"github.com/spf13/cobra"
+)
+
+func cmdStreamTrigger() *cobra.Command {
+	cmd := triggerCmdTemplate(func(sc sharedcfg.SharedConfigs) {
+		sc.AddController(func(cfg sharedcfg.Configs) sharedcfg.Runner {
+			r, err := streamtrigger.NewOperator(
+				cfg.Context(),
+				cfg.RestConfig(),
+				cfg.RefuncClient(),
+				cfg.RefuncInformers(),
+			)
+			if err != nil {
+				klog.Fatalf("Failed to create trigger, %v", err)
+			}
+			return r
+		})
+	})
+
+	cmd.Use = "stream"
+	cmd.Short = "operator for JetStream stream trigger"
+	cmd.Long = cmd.Short
+
+	return cmd
+}
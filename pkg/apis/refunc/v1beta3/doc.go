@@ -35,6 +35,10 @@ const (
 
 	// Annotations to enable API compatible features
 	AnnotationRPCVer = "refunc.io/rpc-version"
+
+	// Annotation to run a cron trigger immediately, the value is any unique token,
+	// it's removed by the operator once the run is started.
+	AnnotationRunNow = "refunc.io/run-now"
 )
 
 var trueVar = true
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 
+	autoscalev2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -62,6 +63,12 @@ type FuncdefSpec struct {
 	// the maximum number of parallel executors
 	// optional, 0 means do not scale
 	MaxReplicas int32 `json:"maxReplicas,omitempty"`
+	// the number of executors initialized with function and kept ready ahead of traffic,
+	// optional, 0 means executors are initialized on demand
+	ProvisionedConcurrency int32 `json:"provisionedConcurrency,omitempty"`
+	// Autoscaling options of executors,
+	// optional, HPA on cpu and memory is used if not set
+	Autoscaling *Autoscaling `json:"autoscaling,omitempty"`
 	// Runtime options for agent and runtime builder
 	Runtime *Runtime `json:"runtime"`
 	// +kubebuilder:validation:Schemaless
@@ -74,6 +81,34 @@ type FuncdefSpec struct {
 	Custom json.RawMessage `json:"custom,omitempty"`
 }
 
+// Autoscaling describes how executors are scaled between MinReplicas and MaxReplicas
+type Autoscaling struct {
+	// TargetConcurrency is the number of in-flight requests per pod to scale toward,
+	// optional, 0 means scaling by HPA
+	TargetConcurrency int32 `json:"targetConcurrency,omitempty"`
+	// TargetCPUUtilization is the average cpu utilization in percentage of HPA,
+	// optional, defaults to 90, 0 means do not scale by cpu
+	TargetCPUUtilization *int32 `json:"targetCPUUtilization,omitempty"`
+	// TargetMemoryUtilization is the average memory utilization in percentage of HPA,
+	// optional, defaults to 90, 0 means do not scale by memory, ignored by autoscaling/v1
+	TargetMemoryUtilization *int32 `json:"targetMemoryUtilization,omitempty"`
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// Metrics are extra metrics of HPA, e.g. custom(Pods, Object) and External metrics,
+	// ignored by autoscaling/v1
+	Metrics []autoscalev2.MetricSpec `json:"metrics,omitempty"`
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// Behavior configures scaling policies of HPA, ignored by autoscaling/v1
+	Behavior *autoscalev2.HorizontalPodAutoscalerBehavior `json:"behavior,omitempty"`
+	// ScaleUpStabilizationSeconds is the window of recommendations to consider when scaling up,
+	// the lowest one is picked, defaults to 0
+	ScaleUpStabilizationSeconds *int32 `json:"scaleUpStabilizationSeconds,omitempty"`
+	// ScaleDownStabilizationSeconds is the window of recommendations to consider when scaling down,
+	// the highest one is picked, defaults to 300
+	ScaleDownStabilizationSeconds *int32 `json:"scaleDownStabilizationSeconds,omitempty"`
+}
+
 // Runtime runtime to operate this template
 type Runtime struct {
 	// name of xenv
@@ -83,6 +118,14 @@ type Runtime struct {
 	Timeout int               `json:"timeout,omitempty"`
 }
 
+// ProvisionedReplicas returns the number of executors should be kept for funcdef
+func (spec *FuncdefSpec) ProvisionedReplicas() int32 {
+	if spec.ProvisionedConcurrency > spec.MinReplicas {
+		return spec.ProvisionedConcurrency
+	}
+	return spec.MinReplicas
+}
+
 // ErrUnknownTriggerType indicates that we cannot processed the given triger sepc
 var ErrUnknownTriggerType = errors.New("refunc: got unknown funcinst type")
 
@@ -66,6 +66,8 @@ type FuncinstStatus struct {
 	Conditions []FuncinstCondition `json:"conditions,omitempty"`
 	// number of active instances
 	Active int `json:"active,omitempty"`
+	// number of provisioned instances that are initialized and ready
+	Provisioned int `json:"provisioned,omitempty"`
 }
 
 // FuncinstConditionType is label to indicates current state for a func
@@ -78,6 +80,14 @@ const (
 	FuncinstActive   FuncinstConditionType = "Active"   // can be invoked
 )
 
+// Reasons of active condition when executors are scaled to zero
+const (
+	// executors are scaled to zero, waiting for requests
+	ReasonScaledToZero = "ScaledToZero"
+	// a request arrived, executors are scaling up
+	ReasonWakeRequested = "WakeRequested"
+)
+
 // FuncinstCondition contains details for the current condition of this funcinst.
 type FuncinstCondition struct {
 	// Type of cluster condition.
@@ -157,6 +167,8 @@ func NewDefaultPermissions(fni *Funcinst, scopeRoot string) Permissions {
 			// request endpoint
 			"refunc.*.*",
 			"refunc.*.*._meta",
+			// common triggers
+			"_refunc._triggers_.*.*",
 			// reply
 			"_INBOX.*",   // old style
 			"_INBOX.*.*", // new style
@@ -166,6 +178,7 @@ func NewDefaultPermissions(fni *Funcinst, scopeRoot string) Permissions {
 			fni.LoggingEndpoint(),
 			fni.CryingEndpoint(),
 			fni.TappingEndpoint(),
+			fni.MetricsEndpoint(),
 		},
 		Subscribe: []string{
 			// public
@@ -199,6 +212,11 @@ func (t *Funcinst) TappingEndpoint() string {
 	return fmt.Sprintf("_refunc._tap_.%s/%s", t.Namespace, t.Name)
 }
 
+// MetricsEndpoint is endpoint for reporting in-flight requests of executors
+func (t *Funcinst) MetricsEndpoint() string {
+	return fmt.Sprintf("_refunc._metrics_.%s/%s", t.Namespace, t.Name)
+}
+
 // EventsSubEndpoint is endpoint for subscribing events within same ns
 func (t *Funcinst) EventsSubEndpoint() string {
 	return fmt.Sprintf("refunc.%s.*.events.>", t.Spec.FuncdefRef.Namespace)
@@ -274,6 +292,41 @@ func (ts *FuncinstStatus) SetActiveCondition(reason, message string) *FuncinstSt
 	return ts.ClearCondition(FuncinstPending).SetCondition(*active)
 }
 
+// SetScaledToZero marks executors of this funcinst are scaled to zero
+func (ts *FuncinstStatus) SetScaledToZero() *FuncinstStatus {
+	return ts.setWaking(ReasonScaledToZero, "Executors are scaled to zero")
+}
+
+// SetWakeRequested marks this funcinst is requested to scale up from zero
+func (ts *FuncinstStatus) SetWakeRequested() *FuncinstStatus {
+	return ts.setWaking(ReasonWakeRequested, "Executors are requested to scale up").Touch()
+}
+
+// IsScaledToZero returns true if executors are scaled to zero and no request has arrived
+func (ts *FuncinstStatus) IsScaledToZero() bool {
+	_, active := getFuncinstCondition(ts, FuncinstActive)
+	return active != nil && active.Status == corev1.ConditionFalse && active.Reason == ReasonScaledToZero
+}
+
+// IsWakeRequested returns true if this funcinst is requested to scale up from zero
+func (ts *FuncinstStatus) IsWakeRequested() bool {
+	_, active := getFuncinstCondition(ts, FuncinstActive)
+	return active != nil && active.Status == corev1.ConditionFalse && active.Reason == ReasonWakeRequested
+}
+
+func (ts *FuncinstStatus) setWaking(reason, message string) *FuncinstStatus {
+	if ts.IsInactiveCondition() {
+		return ts
+	}
+	ts.Active = 0
+	active := ts.ActiveCondition()
+	active.Status = corev1.ConditionFalse
+	active.LastTransitionTime = time.Now().Format(time.RFC3339)
+	active.Reason = reason
+	active.Message = message
+	return ts
+}
+
 // SetInactiveCondition turns this funcinst into inactive
 func (ts *FuncinstStatus) SetInactiveCondition(reason, message string) *FuncinstStatus {
 	return ts.Deactive(
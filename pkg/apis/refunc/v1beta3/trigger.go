@@ -2,6 +2,7 @@ package v1beta3
 
 import (
 	"encoding/json"
+	"fmt"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -24,7 +25,14 @@ var (
 )
 
 // +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
 // +kubebuilder:resource:path=triggers,singular=trigger,shortName=tr
+// +kubebuilder:printcolumn:name="Func",type=string,JSONPath=`.spec.funcName`
+// +kubebuilder:printcolumn:name="Type",type=string,JSONPath=`.spec.type`
+// +kubebuilder:printcolumn:name="Last Schedule",type=date,JSONPath=`.status.lastScheduleTime`
+// +kubebuilder:printcolumn:name="Last Success",type=date,JSONPath=`.status.lastSuccessfulTime`
+// +kubebuilder:printcolumn:name="Next Schedule",type=date,JSONPath=`.status.nextScheduleTime`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
@@ -33,7 +41,8 @@ type Trigger struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
 
-	Spec TriggerSpec `json:"spec"`
+	Spec   TriggerSpec   `json:"spec"`
+	Status TriggerStatus `json:"status,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -51,24 +60,186 @@ type TriggerList struct {
 type TriggerSpec struct {
 	FuncName string `json:"funcName"`
 	Type     string `json:"type"`
+	// Traffic routes a share of requests to a canary version of function,
+	// it applies to eventgateway and http triggers.
+	Traffic *TrafficSplit `json:"traffic,omitempty"`
 
 	TriggerConfig `json:",inline"`
 }
 
+// TriggerStatus is the observed state of a trigger
+type TriggerStatus struct {
+	// LastScheduleTime is the last time a cron trigger was scheduled
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+	// LastSuccessfulTime is the last time a run of cron trigger succeeded
+	LastSuccessfulTime *metav1.Time `json:"lastSuccessfulTime,omitempty"`
+	// NextScheduleTime is the time of next run of cron trigger
+	NextScheduleTime *metav1.Time `json:"nextScheduleTime,omitempty"`
+	// Runs are recent runs, the latest first
+	Runs []TriggerRun `json:"runs,omitempty"`
+	// RolledBack is set once the canary of Traffic is rolled back,
+	// requests are routed to funcName until the canary is updated.
+	RolledBack *TrafficRollbackStatus `json:"rolledBack,omitempty"`
+}
+
+// TrafficSplit splits requests of a trigger between two versions of function,
+// versions are deployed as funcdeves in the same namespace, e.g. hello-v1 and hello-v2.
+// A trigger with Event.Alias works as an alias of versions.
+//
+// The canary is invoked at its own endpoint <ns>/<funcName>, so it requires an eventgateway
+// trigger of funcName, requests are routed to the stable version until the trigger exists.
+// Failures of requests routed to the canary count towards Rollback.
+type TrafficSplit struct {
+	// FuncName is the name of funcdef of the canary version
+	FuncName string `json:"funcName"`
+	// Hash pins the canary to a version of FuncName,
+	// requests are not routed to the canary if its hash differs.
+	Hash string `json:"hash,omitempty"`
+	// Weight is the percentage of requests routed to the canary, 0-100
+	Weight int `json:"weight"`
+	// Rollback routes all requests back to funcName once the canary fails too often,
+	// the canary is never rolled back if not set.
+	Rollback *TrafficRollback `json:"rollback,omitempty"`
+}
+
+// TrafficRollback is the policy of rolling back a canary by its error rate
+type TrafficRollback struct {
+	// MaxErrorRate is the max percentage of failed requests of the canary, defaults to 10
+	MaxErrorRate int `json:"maxErrorRate,omitempty"`
+	// MinRequests is the number of requests in a window before error rate is evaluated, defaults to 20
+	MinRequests int `json:"minRequests,omitempty"`
+	// WindowSeconds is the duration of a window, defaults to 60
+	WindowSeconds int `json:"windowSeconds,omitempty"`
+}
+
+// TrafficRollbackStatus records a rolled back canary
+type TrafficRollbackStatus struct {
+	FuncName string      `json:"funcName"`
+	Hash     string      `json:"hash"`
+	Time     metav1.Time `json:"time"`
+	// Message is the reason of rollback
+	Message string `json:"message,omitempty"`
+}
+
+// IsCanary returns true if fndef is the canary version of trigger
+func (t *Trigger) IsCanary(fndef *Funcdef) bool {
+	split := t.Spec.Traffic
+	return split != nil && split.FuncName != t.Spec.FuncName &&
+		fndef.Namespace == t.Namespace && fndef.Name == split.FuncName
+}
+
+// IsRolledBack returns true if the canary funcName of hash has been rolled back
+func (ts *TriggerStatus) IsRolledBack(funcName, hash string) bool {
+	return ts.RolledBack != nil && ts.RolledBack.FuncName == funcName && ts.RolledBack.Hash == hash
+}
+
+// TriggerRun is an execution of a trigger
+type TriggerRun struct {
+	ID        string      `json:"id"`
+	StartTime metav1.Time `json:"startTime"`
+	// Duration of the run, empty if still running
+	Duration *metav1.Duration `json:"duration,omitempty"`
+	// Phase is one of Running, Succeeded or Failed
+	Phase TriggerRunPhase `json:"phase"`
+	// Message is the error of a failed run
+	Message string `json:"message,omitempty"`
+	// LogKeys are locations of logs in the sink
+	LogKeys []string `json:"logKeys,omitempty"`
+	// ResultKey is location of the result in the sink
+	ResultKey string `json:"resultKey,omitempty"`
+}
+
+// TriggerRunPhase is label to indicate the state of a run
+type TriggerRunPhase string
+
+// Different phases of a run
+const (
+	TriggerRunRunning   TriggerRunPhase = "Running"
+	TriggerRunSucceeded TriggerRunPhase = "Succeeded"
+	TriggerRunFailed    TriggerRunPhase = "Failed"
+)
+
+// MaxTriggerRuns is the max number of runs kept in status
+const MaxTriggerRuns = 10
+
+// AddRun puts run at the front of Runs, the oldest runs are dropped if exceeds MaxTriggerRuns
+func (ts *TriggerStatus) AddRun(run TriggerRun) *TriggerStatus {
+	ts.Runs = append([]TriggerRun{run}, ts.Runs...)
+	if len(ts.Runs) > MaxTriggerRuns {
+		ts.Runs = ts.Runs[:MaxTriggerRuns]
+	}
+	return ts
+}
+
+// GetRun returns the run of id, nil if not found
+func (ts *TriggerStatus) GetRun(id string) *TriggerRun {
+	for i := range ts.Runs {
+		if ts.Runs[i].ID == id {
+			return &ts.Runs[i]
+		}
+	}
+	return nil
+}
+
 // TriggerConfig is configuraion for a specific trigger
 type TriggerConfig struct {
 	Event  *EventTrigger  `json:"event,omitempty"`
 	Cron   *CronTrigger   `json:"cron,omitempty"`
 	HTTP   *HTTPTrigger   `json:"http,omitempty"`
 	Common *CommonTrigger `json:"common,omitempty"`
+	Stream *StreamTrigger `json:"stream,omitempty"`
 }
 
-// EventTrigger is a basic trigger for a funcdef
+// EventTrigger invokes funcdef for every event published by funcs in the same namespace,
+// events are published to refunc.<ns>.<source>.events.<alias>
 type EventTrigger struct {
-	Alias       string   `json:"alias,omitempty"`
+	// Alias is the name of events to subscribe, NATS wildcards are allowed, defaults to trigger's name
+	Alias string `json:"alias,omitempty"`
+	// Sources are names of funcs whose events are subscribed, defaults to all funcs
+	Sources []string `json:"sources,omitempty"`
+	// Middlewares are applied in order to events before invocation, one of
+	// filter:<template>, an event is dropped unless the template renders true
+	// transform:<template>, data of an event is replaced by the rendered json
+	// dedupe:<duration>, drops events whose id is seen in the duration, it's best-effort
+	// as ids are remembered by each replica, while events are spread across replicas
 	Middlewares []string `json:"middlewares,omitempty"`
 }
 
+// StreamTrigger consumes a NATS JetStream stream by a durable pull consumer,
+// and invokes funcdef with batches of records.
+type StreamTrigger struct {
+	// Stream is the name of JetStream stream, streams are shared by namespaces,
+	// so it must be prefixed by the namespace of trigger, e.g. <ns>_orders.
+	Stream string `json:"stream"`
+	// Subject filters messages of the stream, all messages are consumed if empty
+	Subject string `json:"subject,omitempty"`
+	// Durable is the name of consumer, defaults to <ns>_<trigger name>
+	Durable string `json:"durable,omitempty"`
+	// DeliverPolicy is one of all, last or new, defaults to new,
+	// it only applies when the consumer is created.
+	DeliverPolicy string `json:"deliverPolicy,omitempty"`
+	// BatchSize is the max number of records of an invocation, defaults to 10
+	BatchSize int `json:"batchSize,omitempty"`
+	// MaxBatchingWindowMs is the max time to gather records of a batch, defaults to 1000
+	MaxBatchingWindowMs int `json:"maxBatchingWindowMs,omitempty"`
+	// MaxInflight is the max number of concurrent invocations, defaults to 1
+	MaxInflight int `json:"maxInflight,omitempty"`
+	// AckOnSuccess acks records after the invocation succeeds, failed records are redelivered.
+	// Records are acked once received if not set.
+	AckOnSuccess bool `json:"ackOnSuccess,omitempty"`
+	// MaxDeliver is the max number of deliveries of a record, defaults to 5, -1 for unlimited
+	MaxDeliver int `json:"maxDeliver,omitempty"`
+	// AckWaitSeconds is the time before an unacked record is redelivered, defaults to 30
+	AckWaitSeconds int `json:"ackWaitSeconds,omitempty"`
+}
+
+// well known deliver policies of StreamTrigger
+const (
+	StreamDeliverAll  = "all"
+	StreamDeliverLast = "last"
+	StreamDeliverNew  = "new"
+)
+
 // CronTrigger is a funcinst that will be scheduled by cron string
 type CronTrigger struct {
 	Cron string `json:"cron"`
@@ -78,28 +249,168 @@ type CronTrigger struct {
 	// Extra args will be appended to args
 	// $time: RFC3339 formated time
 	// $triggerName: name of trigger
+	// String values are rendered as go templates, see crontrigger for helpers
 	// +kubebuilder:validation:Schemaless
 	// +kubebuilder:pruning:PreserveUnknownFields
 	Args json.RawMessage `json:"args,omitempty"`
 	// If enable will save func exec's log or result to s3.
 	SaveLog    bool `json:"saveLog,omitempty"`
 	SaveResult bool `json:"saveResult,omitempty"`
+	// Sink is where logs and results are saved, defaults to s3
+	Sink *TriggerSink `json:"sink,omitempty"`
+	// StartingDeadlineSeconds is the deadline in seconds for starting a run
+	// that missed its scheduled time, missed runs are always started if not set,
+	// unless more than 100 runs are missed.
+	StartingDeadlineSeconds *int64 `json:"startingDeadlineSeconds,omitempty"`
+	// ConcurrencyPolicy is one of Allow, Forbid or Replace, defaults to Allow
+	ConcurrencyPolicy string `json:"concurrencyPolicy,omitempty"`
+	// Retry is the policy for failed runs, failed runs are not retried if not set
+	Retry *CronTriggerRetry `json:"retry,omitempty"`
+	// Suspend stops scheduling new runs, runs missed while suspended are skipped.
+	// On-demand runs are not affected.
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+// CronTriggerRetry is the retry policy of CronTrigger, a run that still fails after
+// MaxAttempts is saved as a dead letter to the sink of the trigger.
+type CronTriggerRetry struct {
+	// MaxAttempts is the max number of executions of a run, including the first one
+	MaxAttempts int `json:"maxAttempts,omitempty"`
+	// InitialBackoffSeconds is the delay before the first retry, defaults to 1
+	InitialBackoffSeconds int `json:"initialBackoffSeconds,omitempty"`
+	// MaxBackoffSeconds is the upper limit of delays, defaults to 60
+	MaxBackoffSeconds int `json:"maxBackoffSeconds,omitempty"`
+	// RetryOn are errorTypes of failures to retry, all failures are retried if empty
+	RetryOn []string `json:"retryOn,omitempty"`
+	// FailureHandler is the name of function that will be invoked with dead letters
+	FailureHandler string `json:"failureHandler,omitempty"`
 }
 
-// CommonTrigger is a placeholder trigger, for store the trigger config, and the trigger operator maybe not builtin.
+// well known concurrency policies of CronTrigger
+const (
+	// runs are allowed to overlap
+	CronAllowConcurrent = "Allow"
+	// skips a run if the previous one is still running
+	CronForbidConcurrent = "Forbid"
+	// cancels the running one and starts a new run
+	CronReplaceConcurrent = "Replace"
+)
+
+// CommonTrigger is a named, parameterised invocation preset of a funcdef,
+// it's invoked on demand over NATS at CommonEndpoint or over the HTTP gateway.
 type CommonTrigger struct {
+	// Args is the default args, keys in args of callers take precedence
 	// +kubebuilder:validation:Schemaless
 	// +kubebuilder:pruning:PreserveUnknownFields
-	Args       json.RawMessage `json:"args,omitempty"`
-	SaveLog    bool            `json:"saveLog,omitempty"`
-	SaveResult bool            `json:"saveResult,omitempty"`
+	Args json.RawMessage `json:"args,omitempty"`
+	// If enable will save func exec's log or result to sink.
+	SaveLog    bool `json:"saveLog,omitempty"`
+	SaveResult bool `json:"saveResult,omitempty"`
+	// Sink is where logs and results are saved, defaults to s3
+	Sink *TriggerSink `json:"sink,omitempty"`
+	// AuthType is one of jwt, apikey or hmac, the trigger is served over
+	// the HTTP gateway only if it's set, it's not checked over NATS.
+	AuthType string `json:"authType,omitempty"`
+	// Auth is configuration for the AuthType
+	Auth *HTTPTriggerAuth `json:"auth,omitempty"`
+	// RateLimit enables admission control of requests over the HTTP gateway
+	RateLimit *HTTPTriggerRateLimit `json:"rateLimit,omitempty"`
+}
+
+// TriggerSink selects the sink of logs and results of a trigger
+type TriggerSink struct {
+	// Name is one of s3, fs, nats or func, defaults to s3
+	Name string `json:"name,omitempty"`
+	// Config of the sink, it's the sub directory for fs,
+	// the subject for nats and the function name for func.
+	Config string `json:"config,omitempty"`
 }
 
 // HTTPTrigger is a funcinst that will react at HTTP requests
 // https://docs.aws.amazon.com/lambda/latest/dg/lambda-urls.html
 type HTTPTrigger struct {
-	AuthType string          `json:"authType,omitempty"`
-	Cors     HTTPTriggerCors `json:"cors,omitempty"`
+	// AuthType is one of none, jwt, apikey or hmac, defaults to none
+	AuthType string `json:"authType,omitempty"`
+	// Auth is configuration for the AuthType
+	Auth *HTTPTriggerAuth `json:"auth,omitempty"`
+	Cors HTTPTriggerCors  `json:"cors,omitempty"`
+	// Cache enables response caching for GET and HEAD requests
+	Cache *HTTPTriggerCache `json:"cache,omitempty"`
+	// CollapseRequests makes concurrent identical requests share one invocation,
+	// requests are identical if method, path, query, body, credentials and
+	// the VaryHeaders of Cache are the same. Streamed requests are never collapsed.
+	CollapseRequests bool `json:"collapseRequests,omitempty"`
+	// RateLimit enables admission control of requests
+	RateLimit *HTTPTriggerRateLimit `json:"rateLimit,omitempty"`
+	// Routes are templates of accepted requests like "GET /users/{id}" or "/orders",
+	// requests that match no route are rejected, all requests are accepted if empty.
+	Routes []string `json:"routes,omitempty"`
+	// Hosts are custom domains the trigger is exposed at besides /<ns>/<funcName>,
+	// host with wildcards like {subdomain}.example.com is supported.
+	Hosts []string `json:"hosts,omitempty"`
+	// PathPrefix is where the trigger is mounted on Hosts, defaults to /
+	PathPrefix string `json:"pathPrefix,omitempty"`
+	// PayloadFormat is one of 2.0, 1.0, alb or raw, defaults to 2.0
+	PayloadFormat string `json:"payloadFormat,omitempty"`
+}
+
+// well known auth types of HTTPTrigger
+const (
+	HTTPAuthNone   = "none"
+	HTTPAuthJWT    = "jwt"
+	HTTPAuthAPIKey = "apikey"
+	HTTPAuthHMAC   = "hmac"
+)
+
+// well known payload formats of HTTPTrigger
+const (
+	// API Gateway HTTP API
+	HTTPPayloadFormatV2 = "2.0"
+	// API Gateway REST API
+	HTTPPayloadFormatV1 = "1.0"
+	// Application Load Balancer
+	HTTPPayloadFormatALB = "alb"
+	// body as is, with headers in options
+	HTTPPayloadFormatRaw = "raw"
+)
+
+// HTTPTriggerAuth is configuration of authentication for a HTTPTrigger
+type HTTPTriggerAuth struct {
+	// Header carries the credential, defaults to Authorization for jwt,
+	// X-API-Key for apikey and X-Refunc-Signature for hmac.
+	Header string `json:"header,omitempty"`
+	// SecretName is the name of Secret in the trigger's namespace,
+	// for apikey every value of the Secret is a valid key,
+	// for hmac the value of SecretKey is the signing key.
+	SecretName string `json:"secretName,omitempty"`
+	// SecretKey selects a single key of the Secret, defaults to "key" for hmac
+	SecretKey string `json:"secretKey,omitempty"`
+	// Audience is required by jwt, tokens must be issued with it in the aud claim
+	Audience string `json:"audience,omitempty"`
+	// Issuer is the iss claim required by jwt if set
+	Issuer string `json:"issuer,omitempty"`
+	// Subjects are sub claims allowed by jwt, all subjects are allowed if empty
+	Subjects []string `json:"subjects,omitempty"`
+}
+
+// well known keys to split quotas of a HTTPTriggerRateLimit
+const (
+	RateLimitKeyBySourceIP = "sourceIP"
+	RateLimitKeyBySubject  = "subject"
+)
+
+// HTTPTriggerRateLimit limits requests of a HTTPTrigger,
+// requests exceeding the limits are rejected with 429.
+type HTTPTriggerRateLimit struct {
+	// RPS is requests per second allowed, zero means unlimited
+	RPS int `json:"rps,omitempty"`
+	// Burst is max requests allowed at once, defaults to RPS
+	Burst int `json:"burst,omitempty"`
+	// MaxInFlight is max concurrent requests, zero means unlimited
+	MaxInFlight int `json:"maxInFlight,omitempty"`
+	// KeyBy applies limits per sourceIP or authenticated subject,
+	// limits are applied to the whole trigger if empty.
+	KeyBy string `json:"keyBy,omitempty"`
 }
 
 type HTTPTriggerCors struct {
@@ -111,6 +422,19 @@ type HTTPTriggerCors struct {
 	MaxAge           int      `json:"maxAge,omitempty"`
 }
 
+// HTTPTriggerCache is configuration of response cache for a HTTPTrigger,
+// cached responses are keyed by function hash, method, path, query and VaryHeaders,
+// thus are invalidated once the Funcdef is updated.
+type HTTPTriggerCache struct {
+	// TTL in seconds of a cached response, defaults to 60,
+	// max-age or s-maxage in the function's Cache-Control overrides it.
+	TTL int `json:"ttl,omitempty"`
+	// VaryHeaders are request headers that are part of the cache key
+	VaryHeaders []string `json:"varyHeaders,omitempty"`
+	// IgnoreCacheControl ignores Cache-Control of both requests and responses
+	IgnoreCacheControl bool `json:"ignoreCacheControl,omitempty"`
+}
+
 // AsOwner returns *metav1.OwnerReference
 func (t *Trigger) AsOwner() *metav1.OwnerReference {
 	return &metav1.OwnerReference{
@@ -135,3 +459,8 @@ func (t *Trigger) Ref() *corev1.ObjectReference {
 		UID:        t.UID,
 	}
 }
+
+// CommonEndpoint is endpoint for invoking a common trigger
+func (t *Trigger) CommonEndpoint() string {
+	return fmt.Sprintf("_refunc._triggers_.%s.%s", t.Namespace, t.Name)
+}
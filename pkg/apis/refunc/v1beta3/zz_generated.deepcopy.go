@@ -14,10 +14,60 @@ package v1beta3
 import (
 	json "encoding/json"
 
+	v2 "k8s.io/api/autoscaling/v2"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Autoscaling) DeepCopyInto(out *Autoscaling) {
+	*out = *in
+	if in.TargetCPUUtilization != nil {
+		in, out := &in.TargetCPUUtilization, &out.TargetCPUUtilization
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TargetMemoryUtilization != nil {
+		in, out := &in.TargetMemoryUtilization, &out.TargetMemoryUtilization
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Metrics != nil {
+		in, out := &in.Metrics, &out.Metrics
+		*out = make([]v2.MetricSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Behavior != nil {
+		in, out := &in.Behavior, &out.Behavior
+		*out = new(v2.HorizontalPodAutoscalerBehavior)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ScaleUpStabilizationSeconds != nil {
+		in, out := &in.ScaleUpStabilizationSeconds, &out.ScaleUpStabilizationSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ScaleDownStabilizationSeconds != nil {
+		in, out := &in.ScaleDownStabilizationSeconds, &out.ScaleDownStabilizationSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Autoscaling.
+func (in *Autoscaling) DeepCopy() *Autoscaling {
+	if in == nil {
+		return nil
+	}
+	out := new(Autoscaling)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CommonTrigger) DeepCopyInto(out *CommonTrigger) {
 	*out = *in
@@ -26,6 +76,21 @@ func (in *CommonTrigger) DeepCopyInto(out *CommonTrigger) {
 		*out = make(json.RawMessage, len(*in))
 		copy(*out, *in)
 	}
+	if in.Sink != nil {
+		in, out := &in.Sink, &out.Sink
+		*out = new(TriggerSink)
+		**out = **in
+	}
+	if in.Auth != nil {
+		in, out := &in.Auth, &out.Auth
+		*out = new(HTTPTriggerAuth)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RateLimit != nil {
+		in, out := &in.RateLimit, &out.RateLimit
+		*out = new(HTTPTriggerRateLimit)
+		**out = **in
+	}
 	return
 }
 
@@ -63,6 +128,21 @@ func (in *CronTrigger) DeepCopyInto(out *CronTrigger) {
 		*out = make(json.RawMessage, len(*in))
 		copy(*out, *in)
 	}
+	if in.Sink != nil {
+		in, out := &in.Sink, &out.Sink
+		*out = new(TriggerSink)
+		**out = **in
+	}
+	if in.StartingDeadlineSeconds != nil {
+		in, out := &in.StartingDeadlineSeconds, &out.StartingDeadlineSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Retry != nil {
+		in, out := &in.Retry, &out.Retry
+		*out = new(CronTriggerRetry)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -76,9 +156,35 @@ func (in *CronTrigger) DeepCopy() *CronTrigger {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CronTriggerRetry) DeepCopyInto(out *CronTriggerRetry) {
+	*out = *in
+	if in.RetryOn != nil {
+		in, out := &in.RetryOn, &out.RetryOn
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CronTriggerRetry.
+func (in *CronTriggerRetry) DeepCopy() *CronTriggerRetry {
+	if in == nil {
+		return nil
+	}
+	out := new(CronTriggerRetry)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EventTrigger) DeepCopyInto(out *EventTrigger) {
 	*out = *in
+	if in.Sources != nil {
+		in, out := &in.Sources, &out.Sources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Middlewares != nil {
 		in, out := &in.Middlewares, &out.Middlewares
 		*out = make([]string, len(*in))
@@ -160,6 +266,11 @@ func (in *FuncdefList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FuncdefSpec) DeepCopyInto(out *FuncdefSpec) {
 	*out = *in
+	if in.Autoscaling != nil {
+		in, out := &in.Autoscaling, &out.Autoscaling
+		*out = new(Autoscaling)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Runtime != nil {
 		in, out := &in.Runtime, &out.Runtime
 		*out = new(Runtime)
@@ -316,7 +427,32 @@ func (in *FuncinstStatus) DeepCopy() *FuncinstStatus {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HTTPTrigger) DeepCopyInto(out *HTTPTrigger) {
 	*out = *in
+	if in.Auth != nil {
+		in, out := &in.Auth, &out.Auth
+		*out = new(HTTPTriggerAuth)
+		(*in).DeepCopyInto(*out)
+	}
 	in.Cors.DeepCopyInto(&out.Cors)
+	if in.Cache != nil {
+		in, out := &in.Cache, &out.Cache
+		*out = new(HTTPTriggerCache)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RateLimit != nil {
+		in, out := &in.RateLimit, &out.RateLimit
+		*out = new(HTTPTriggerRateLimit)
+		**out = **in
+	}
+	if in.Routes != nil {
+		in, out := &in.Routes, &out.Routes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -330,6 +466,48 @@ func (in *HTTPTrigger) DeepCopy() *HTTPTrigger {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPTriggerAuth) DeepCopyInto(out *HTTPTriggerAuth) {
+	*out = *in
+	if in.Subjects != nil {
+		in, out := &in.Subjects, &out.Subjects
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPTriggerAuth.
+func (in *HTTPTriggerAuth) DeepCopy() *HTTPTriggerAuth {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPTriggerAuth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPTriggerCache) DeepCopyInto(out *HTTPTriggerCache) {
+	*out = *in
+	if in.VaryHeaders != nil {
+		in, out := &in.VaryHeaders, &out.VaryHeaders
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPTriggerCache.
+func (in *HTTPTriggerCache) DeepCopy() *HTTPTriggerCache {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPTriggerCache)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HTTPTriggerCors) DeepCopyInto(out *HTTPTriggerCors) {
 	*out = *in
@@ -366,6 +544,22 @@ func (in *HTTPTriggerCors) DeepCopy() *HTTPTriggerCors {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPTriggerRateLimit) DeepCopyInto(out *HTTPTriggerRateLimit) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPTriggerRateLimit.
+func (in *HTTPTriggerRateLimit) DeepCopy() *HTTPTriggerRateLimit {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPTriggerRateLimit)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Permissions) DeepCopyInto(out *Permissions) {
 	*out = *in
@@ -433,12 +627,83 @@ func (in *RuntimeContext) DeepCopy() *RuntimeContext {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StreamTrigger) DeepCopyInto(out *StreamTrigger) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StreamTrigger.
+func (in *StreamTrigger) DeepCopy() *StreamTrigger {
+	if in == nil {
+		return nil
+	}
+	out := new(StreamTrigger)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrafficRollback) DeepCopyInto(out *TrafficRollback) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrafficRollback.
+func (in *TrafficRollback) DeepCopy() *TrafficRollback {
+	if in == nil {
+		return nil
+	}
+	out := new(TrafficRollback)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrafficRollbackStatus) DeepCopyInto(out *TrafficRollbackStatus) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrafficRollbackStatus.
+func (in *TrafficRollbackStatus) DeepCopy() *TrafficRollbackStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TrafficRollbackStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrafficSplit) DeepCopyInto(out *TrafficSplit) {
+	*out = *in
+	if in.Rollback != nil {
+		in, out := &in.Rollback, &out.Rollback
+		*out = new(TrafficRollback)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrafficSplit.
+func (in *TrafficSplit) DeepCopy() *TrafficSplit {
+	if in == nil {
+		return nil
+	}
+	out := new(TrafficSplit)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Trigger) DeepCopyInto(out *Trigger) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -483,6 +748,11 @@ func (in *TriggerConfig) DeepCopyInto(out *TriggerConfig) {
 		*out = new(CommonTrigger)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Stream != nil {
+		in, out := &in.Stream, &out.Stream
+		*out = new(StreamTrigger)
+		**out = **in
+	}
 	return
 }
 
@@ -529,9 +799,57 @@ func (in *TriggerList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TriggerRun) DeepCopyInto(out *TriggerRun) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.LogKeys != nil {
+		in, out := &in.LogKeys, &out.LogKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TriggerRun.
+func (in *TriggerRun) DeepCopy() *TriggerRun {
+	if in == nil {
+		return nil
+	}
+	out := new(TriggerRun)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TriggerSink) DeepCopyInto(out *TriggerSink) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TriggerSink.
+func (in *TriggerSink) DeepCopy() *TriggerSink {
+	if in == nil {
+		return nil
+	}
+	out := new(TriggerSink)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TriggerSpec) DeepCopyInto(out *TriggerSpec) {
 	*out = *in
+	if in.Traffic != nil {
+		in, out := &in.Traffic, &out.Traffic
+		*out = new(TrafficSplit)
+		(*in).DeepCopyInto(*out)
+	}
 	in.TriggerConfig.DeepCopyInto(&out.TriggerConfig)
 	return
 }
@@ -546,6 +864,46 @@ func (in *TriggerSpec) DeepCopy() *TriggerSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TriggerStatus) DeepCopyInto(out *TriggerStatus) {
+	*out = *in
+	if in.LastScheduleTime != nil {
+		in, out := &in.LastScheduleTime, &out.LastScheduleTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSuccessfulTime != nil {
+		in, out := &in.LastSuccessfulTime, &out.LastSuccessfulTime
+		*out = (*in).DeepCopy()
+	}
+	if in.NextScheduleTime != nil {
+		in, out := &in.NextScheduleTime, &out.NextScheduleTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Runs != nil {
+		in, out := &in.Runs, &out.Runs
+		*out = make([]TriggerRun, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RolledBack != nil {
+		in, out := &in.RolledBack, &out.RolledBack
+		*out = new(TrafficRollbackStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TriggerStatus.
+func (in *TriggerStatus) DeepCopy() *TriggerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TriggerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Xenv) DeepCopyInto(out *Xenv) {
 	*out = *in
@@ -1,14 +1,9 @@
 package sign
 
 import (
-	"crypto/ecdsa"
 	"encoding/json"
 	"errors"
-	"fmt"
-	"io/ioutil"
-	"os"
 	"strings"
-	"sync"
 
 	jwt "github.com/golang-jwt/jwt"
 	"github.com/refunc/refunc/pkg/builtins"
@@ -47,18 +42,11 @@ func signToken(request *messages.InvokeRequest) (token interface{}, err error) {
 }
 
 func verify(tokenString string) error {
-	token, err := jwt.ParseWithClaims(tokenString, jwt.MapClaims{}, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodECDSA); !ok {
-			return nil, fmt.Errorf("expect token signed with ECDSA but got %v", t.Header["alg"])
-		}
-		return publicKey()
-	})
-
+	claims, err := rfutil.Verify(tokenString)
 	if err != nil {
-		// break on first correctly validated token
 		return err
 	}
-	v, has := token.Claims.(jwt.MapClaims)["sign"]
+	v, has := claims["sign"]
 	if !has {
 		return errUnauthorized
 	}
@@ -75,38 +63,6 @@ func verify(tokenString string) error {
 	return errUnauthorized
 }
 
-var (
-	// public ECDSA key to sign token
-	publicECDSAKeyFile string
-
-	pubKey      *ecdsa.PublicKey
-	loadKeyErr  error
-	loadKeyOnce sync.Once
-)
-
-func publicKey() (*ecdsa.PublicKey, error) {
-	loadKeyOnce.Do(func() {
-		if publicECDSAKeyFile == "" {
-			loadKeyErr = errors.New("sign: missing public to verify")
-			return
-		}
-		f, err := os.Open(publicECDSAKeyFile)
-		if err != nil {
-			loadKeyErr = err
-			return
-		}
-		defer f.Close()
-		keyBytes, err := ioutil.ReadAll(f)
-		if err != nil {
-			loadKeyErr = err
-			return
-		}
-		pubKey, loadKeyErr = jwt.ParseECPublicKeyFromPEM(keyBytes)
-	})
-	return pubKey, loadKeyErr
-}
-
 func init() {
-	publicECDSAKeyFile = os.Getenv("ECDSA_PUBLICKEY_FILE")
 	builtins.RegisterBuiltin("sign", "", signToken)
 }
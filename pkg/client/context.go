@@ -92,6 +92,14 @@ func WithNatsConn(parent context.Context, conn *nats.Conn) context.Context {
 	return parent
 }
 
+// GetNatsConn returns the nats connection set by WithNatsConn, nil if not set
+func GetNatsConn(ctx context.Context) *nats.Conn {
+	if v := ctx.Value(natsKey); v != nil {
+		return v.(*nats.Conn)
+	}
+	return nil
+}
+
 // WithLoggingHint sets log forwarding hint
 func WithLoggingHint(parent context.Context, enabled bool) context.Context {
 	return context.WithValue(parent, logKey, enabled)
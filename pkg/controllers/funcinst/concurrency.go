@@ -0,0 +1,257 @@
+package funcinst
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+
+	nats "github.com/nats-io/nats.go"
+	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
+	"github.com/refunc/refunc/pkg/messages"
+	"github.com/refunc/refunc/pkg/utils"
+	"github.com/refunc/refunc/pkg/utils/rfutil"
+)
+
+const (
+	metricsPrefix = "_refunc._metrics_."
+
+	// executors report every 2s, metrics older than this are ignored
+	metricsExpiration = 10 * time.Second
+
+	scaleInterval = 2 * time.Second
+
+	defaultScaleDownStabilization = 300 * time.Second
+)
+
+type podMetrics struct {
+	messages.InflightMetrics
+	time time.Time
+}
+
+type recommendation struct {
+	time     time.Time
+	replicas int32
+}
+
+// concurrencyStats collects in-flight metrics and scale recommendations of a funcinst
+type concurrencyStats struct {
+	sync.Mutex
+
+	pods            map[string]podMetrics
+	recommendations []recommendation
+}
+
+// scaledByConcurrency returns true if replicas of fndef are scaled by in-flight requests instead of HPA
+func (rc *Controller) scaledByConcurrency(fndef *rfv1beta3.Funcdef) bool {
+	return rc.NatsConn != nil &&
+		fndef.Spec.Autoscaling != nil && fndef.Spec.Autoscaling.TargetConcurrency > 0 &&
+		fndef.Spec.MaxReplicas > 1 && fndef.Spec.MaxReplicas > fndef.Spec.ProvisionedReplicas()
+}
+
+// concurrencyScaler collects metrics reported by executors and scales replicasets periodically
+func (rc *Controller) concurrencyScaler(stopC <-chan struct{}) {
+	sub, err := rc.NatsConn.Subscribe(metricsPrefix+"*", rc.onMetrics)
+	if err != nil {
+		klog.Errorf("(tc) failed to subscribe metrics, %v", err)
+		return
+	}
+	defer sub.Unsubscribe() // nolint:errcheck
+
+	klog.Info("(tc) concurrency scaler started")
+	defer klog.Info("(tc) concurrency scaler stopped")
+
+	ticker := time.NewTicker(scaleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-stopC:
+			return
+		}
+
+		rc.scaleByConcurrency(time.Now())
+	}
+}
+
+func (rc *Controller) onMetrics(msg *nats.Msg) {
+	key := msg.Subject[len(metricsPrefix):]
+	if splitted := strings.SplitN(key, "/", 2); len(splitted) != 2 {
+		klog.Errorf("(tc) malformed metrics message on %s", msg.Subject)
+		return
+	}
+	var metrics messages.InflightMetrics
+	if err := json.Unmarshal(msg.Data, &metrics); err != nil {
+		klog.Errorf("(tc) malformed metrics message on %s, %v", msg.Subject, err)
+		return
+	}
+
+	val, _ := rc.concurrency.LoadOrStore(key, &concurrencyStats{pods: make(map[string]podMetrics)})
+	stats := val.(*concurrencyStats)
+	stats.Lock()
+	stats.pods[metrics.Pod] = podMetrics{InflightMetrics: metrics, time: time.Now()}
+	stats.Unlock()
+}
+
+func (rc *Controller) scaleByConcurrency(now time.Time) {
+	defer func() {
+		if re := recover(); re != nil {
+			utils.LogTraceback(re, 4, klog.V(1))
+		}
+	}()
+
+	rc.concurrency.Range(func(k, v interface{}) bool {
+		key := k.(string)
+		namespace, name, err := cache.SplitMetaNamespaceKey(key)
+		if err != nil {
+			rc.concurrency.Delete(key)
+			return true
+		}
+		fni, err := rc.funcinstLister.Funcinsts(namespace).Get(name)
+		if err != nil || fni.Status.IsInactiveCondition() {
+			rc.concurrency.Delete(key)
+			return true
+		}
+		fndef, err := rc.funcdefLister.Funcdeves(fni.Spec.FuncdefRef.Namespace).Get(fni.Spec.FuncdefRef.Name)
+		if err != nil || !rc.scaledByConcurrency(fndef) {
+			return true
+		}
+		if err := rc.scaleFuncinst(fni, fndef, v.(*concurrencyStats), now); err != nil {
+			klog.Warningf("(tc) failed to scale %q, %v", key, err)
+		}
+		return true
+	})
+}
+
+func (rc *Controller) scaleFuncinst(fni *rfv1beta3.Funcinst, fndef *rfv1beta3.Funcdef, stats *concurrencyStats, now time.Time) error {
+	rs, err := rc.getRuntimeReplciaSetFromCache(fni)
+	if err != nil || rs == nil {
+		return err
+	}
+	var current int32 = 1
+	if rs.Spec.Replicas != nil {
+		current = *rs.Spec.Replicas
+	}
+	if current == 0 {
+		// scaled to zero, waked by requests
+		return nil
+	}
+
+	as := fndef.Spec.Autoscaling
+	upWindow, downWindow := time.Duration(0), defaultScaleDownStabilization
+	if as.ScaleUpStabilizationSeconds != nil {
+		upWindow = time.Duration(*as.ScaleUpStabilizationSeconds) * time.Second
+	}
+	if as.ScaleDownStabilizationSeconds != nil {
+		downWindow = time.Duration(*as.ScaleDownStabilizationSeconds) * time.Second
+	}
+
+	stats.Lock()
+	inflight, concurrency := stats.inflight(now)
+	target := as.TargetConcurrency
+	if concurrency > 0 && int32(concurrency) < target {
+		// requests beyond concurrency are queued in executors
+		target = int32(concurrency)
+	}
+	desired := desiredReplicas(inflight, target, fndef.Spec.ProvisionedReplicas(), fndef.Spec.MaxReplicas)
+	replicas := stats.stabilize(now, current, desired, upWindow, downWindow)
+	stats.Unlock()
+
+	if replicas == current {
+		return nil
+	}
+
+	klog.V(3).Infof("(tc) scaling %s/%s from %d -> %d, #%d in-flight", fni.Namespace, fni.Name, current, replicas, inflight)
+	// conflicts will be retried at next round
+	return rc.scaleRuntimeReplicaSet(rs, replicas)
+}
+
+func (rc *Controller) getRuntimeReplciaSetFromCache(fni *rfv1beta3.Funcinst) (*appsv1.ReplicaSet, error) {
+	rss, err := rc.rsLister.ReplicaSets(fni.Namespace).List(labels.Set(rfutil.ExecutorLabels(fni)).AsSelector())
+	if err != nil {
+		return nil, err
+	}
+	for _, rs := range rss {
+		if rs.DeletionTimestamp != nil {
+			continue
+		}
+		if ctlRef := metav1.GetControllerOf(rs); ctlRef != nil && ctlRef.UID == fni.UID {
+			return rs, nil
+		}
+	}
+	return nil, nil
+}
+
+// inflight sums up in-flight requests of alive pods, and returns the concurrency of a pod
+func (s *concurrencyStats) inflight(now time.Time) (inflight, concurrency int) {
+	for pod, m := range s.pods {
+		if now.Sub(m.time) > metricsExpiration {
+			delete(s.pods, pod)
+			continue
+		}
+		inflight += m.Inflight
+		if m.Concurrency > concurrency {
+			concurrency = m.Concurrency
+		}
+	}
+	return
+}
+
+// stabilize records desired replicas and returns replicas to scale to,
+// the lowest recommendation within upWindow is used to scale up,
+// and the highest one within downWindow is used to scale down.
+func (s *concurrencyStats) stabilize(now time.Time, current, desired int32, upWindow, downWindow time.Duration) int32 {
+	s.recommendations = append(s.recommendations, recommendation{time: now, replicas: desired})
+
+	longest := upWindow
+	if downWindow > longest {
+		longest = downWindow
+	}
+	up, down := desired, desired
+	kept := s.recommendations[:0]
+	for _, r := range s.recommendations {
+		age := now.Sub(r.time)
+		if age > longest {
+			continue
+		}
+		kept = append(kept, r)
+		if age <= upWindow && r.replicas < up {
+			up = r.replicas
+		}
+		if age <= downWindow && r.replicas > down {
+			down = r.replicas
+		}
+	}
+	s.recommendations = kept
+
+	switch {
+	case current < up:
+		return up
+	case current > down:
+		return down
+	}
+	return current
+}
+
+// desiredReplicas returns replicas to serve inflight requests with target concurrency per pod
+func desiredReplicas(inflight int, target, minReplicas, maxReplicas int32) int32 {
+	if minReplicas < 1 {
+		minReplicas = 1
+	}
+	replicas := int32(math.Ceil(float64(inflight) / float64(target)))
+	if replicas < minReplicas {
+		return minReplicas
+	}
+	if replicas > maxReplicas {
+		return maxReplicas
+	}
+	return replicas
+}
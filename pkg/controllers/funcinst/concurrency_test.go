@@ -0,0 +1,54 @@
+package funcinst
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDesiredReplicas(t *testing.T) {
+	cases := []struct {
+		inflight       int
+		target         int32
+		min, max, want int32
+	}{
+		{0, 10, 0, 5, 1},
+		{0, 10, 2, 5, 2},
+		{10, 10, 0, 5, 1},
+		{11, 10, 0, 5, 2},
+		{100, 10, 0, 5, 5},
+	}
+	for i, c := range cases {
+		if got := desiredReplicas(c.inflight, c.target, c.min, c.max); got != c.want {
+			t.Errorf("#%d desiredReplicas(%d, %d) = %d, want %d", i, c.inflight, c.target, got, c.want)
+		}
+	}
+}
+
+func TestStabilize(t *testing.T) {
+	var (
+		stats = &concurrencyStats{}
+		t0    = time.Now()
+		up    = 10 * time.Second
+		down  = 60 * time.Second
+	)
+	steps := []struct {
+		after   time.Duration
+		current int32
+		desired int32
+		want    int32
+	}{
+		// scales up after the lowest recommendation in up window is higher
+		{0, 1, 4, 4},
+		{5 * time.Second, 1, 3, 3},
+		// holds while recommendations in down window are higher
+		{20 * time.Second, 3, 1, 3},
+		{40 * time.Second, 3, 1, 3},
+		// scales down once higher recommendations expire
+		{70 * time.Second, 3, 1, 1},
+	}
+	for i, s := range steps {
+		if got := stats.stabilize(t0.Add(s.after), s.current, s.desired, up, down); got != s.want {
+			t.Errorf("#%d stabilize(%d, %d) = %d, want %d", i, s.current, s.desired, got, s.want)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package funcinst
 
 import (
+	"sync"
 	"time"
 
 	"k8s.io/apimachinery/pkg/api/meta"
@@ -17,6 +18,7 @@ import (
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog"
 
+	nats "github.com/nats-io/nats.go"
 	refunc "github.com/refunc/refunc/pkg/generated/clientset/versioned"
 	rfinformers "github.com/refunc/refunc/pkg/generated/informers/externalversions"
 	rflistersv1 "github.com/refunc/refunc/pkg/generated/listers/refunc/v1beta3"
@@ -28,6 +30,15 @@ type Controller struct {
 	GCInterval  time.Duration
 	IdleDuraion time.Duration
 
+	// ScaleToZero keeps idle funcinsts scaled to zero instead of collecting them
+	ScaleToZero bool
+	// ScaledToZeroTTL is the idle duration after which a funcinst scaled to zero is collected,
+	// it's kept until funcdef is changed or removed if not positive.
+	ScaledToZeroTTL time.Duration
+
+	// NatsConn enables scaling by in-flight requests if set
+	NatsConn *nats.Conn
+
 	cfg rest.Config // keep a copy of config
 
 	rclient refunc.Interface
@@ -40,6 +51,7 @@ type Controller struct {
 	hpaV1Lister      autoscalev1.HorizontalPodAutoscalerLister
 	hpaV2Lister      autoscalev2.HorizontalPodAutoscalerLister
 	deploymentLister appsv1.DeploymentLister
+	rsLister         appsv1.ReplicaSetLister
 	podLister        corev1.PodLister
 
 	funcdefLister  rflistersv1.FuncdefLister
@@ -47,6 +59,9 @@ type Controller struct {
 	funcinstLister rflistersv1.FuncinstLister
 	xenvLister     rflistersv1.XenvLister
 
+	// in-flight metrics of funcinsts
+	concurrency sync.Map
+
 	// working queeu, synced tasks
 	queue           workqueue.RateLimitingInterface
 	wantedInformers []cache.InformerSynced
@@ -81,6 +96,7 @@ func NewController(
 
 	// config listers
 	r.deploymentLister = kubeinformers.Apps().V1().Deployments().Lister()
+	r.rsLister = kubeinformers.Apps().V1().ReplicaSets().Lister()
 	r.podLister = kubeinformers.Core().V1().Pods().Lister()
 	r.hpaV1Lister = kubeinformers.Autoscaling().V1().HorizontalPodAutoscalers().Lister()
 	r.hpaV2Lister = nil
@@ -174,6 +190,10 @@ func (rc *Controller) Run(workers int, stopC <-chan struct{}) {
 
 	go rc.gcMonitor(stopC)
 
+	if rc.NatsConn != nil {
+		go rc.concurrencyScaler(stopC)
+	}
+
 	<-stopC
 }
 
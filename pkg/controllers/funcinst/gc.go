@@ -66,8 +66,13 @@ func (rc *Controller) collectGarbadge() {
 			}
 
 			if err == nil && !funcinst.Status.IsInactiveCondition() {
-				// check if we should skip checking
-				if func() (skip bool) {
+				if funcinst.Status.IsScaledToZero() {
+					if rc.ScaledToZeroTTL <= 0 || time.Since(funcinst.Status.LastActivity()) < rc.ScaledToZeroTTL {
+						// kept until funcdef is changed or removed
+						return
+					}
+					klog.Infof("(tc:gc) %q is scaled to zero longer than %v", key, rc.ScaledToZeroTTL)
+				} else if func() (skip bool) {
 					cnt := fniCounter[fnkey]
 					if cnt == -1 {
 						klog.Errorf("(tc:gc) %q(%s) max replicas reached", fnkey, funcinst.Name)
@@ -77,7 +82,7 @@ func (rc *Controller) collectGarbadge() {
 						return false
 					}
 
-					if fndef.Spec.MinReplicas > 0 && cnt < fndef.Spec.MinReplicas {
+					if provisioned := fndef.Spec.ProvisionedReplicas(); provisioned > 0 && cnt < provisioned {
 						fniCounter[fnkey] = cnt + int32(funcinst.Status.Active)
 						return true
 					}
@@ -91,8 +96,14 @@ func (rc *Controller) collectGarbadge() {
 						}
 						return true
 					}
-					klog.Infof("(tc:gc) %q is old enough to be collected", key)
-					return false
+					if !rc.ScaleToZero {
+						return false
+					}
+					klog.Infof("(tc:gc) %q is idle long enough to be scaled to zero", key)
+					if err := rc.scaleToZero(funcinst); err != nil {
+						klog.Errorf("(tc:gc) failed to scale %q to zero, %v", key, err)
+					}
+					return true
 				}() {
 					// we should skip this turn
 					return
@@ -5,9 +5,13 @@ import (
 
 	autoscalev1 "k8s.io/api/autoscaling/v1"
 	autoscalev2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
 
 	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
+	"github.com/refunc/refunc/pkg/utils/k8sutil"
 	"github.com/refunc/refunc/pkg/utils/rfutil"
 )
 
@@ -27,12 +31,159 @@ func (rc *Controller) getHorizontalPodAutoscalerV1(funcinst *rfv1beta3.Funcinst)
 	return as, nil
 }
 
-var (
-	minReplicas  int32 = 1
-	targetCPU    int32 = 90
-	targetMemory int32 = 90
+// syncHorizontalPodAutoscalerV2 creates hpa for funcinst, or updates it if autoscaling of fndef is changed
+func (rc *Controller) syncHorizontalPodAutoscalerV2(fni *rfv1beta3.Funcinst, fndef *rfv1beta3.Funcdef) error {
+	hpa, err := rc.hpaV2Lister.HorizontalPodAutoscalers(fni.Namespace).Get(fni.Name)
+	if hpa == nil || k8sutil.IsResourceNotFoundError(err) {
+		klog.Infof("(tc) creating horizontalPodAutoscaler for %q", fni.Name)
+		rs, err := rc.getRuntimeReplciaSet(fni)
+		if err != nil {
+			return err
+		}
+		hpa = rc.horizontalPodAutoscalerV2(fni, fndef, rs.GetName())
+		if err = retryOnceOnError(func() error {
+			hpa, err = rc.kclient.AutoscalingV2().HorizontalPodAutoscalers(fni.Namespace).Create(context.TODO(), hpa, metav1.CreateOptions{})
+			if apierrors.IsAlreadyExists(err) {
+				hpa, err = rc.getHorizontalPodAutoscalerV2(fni)
+			}
+			return err
+		}); err == nil {
+			return nil
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	want := rc.horizontalPodAutoscalerV2(fni, fndef, hpa.Spec.ScaleTargetRef.Name)
+	if hpa.Labels[rfv1beta3.LabelSpecHash] == want.Labels[rfv1beta3.LabelSpecHash] {
+		return nil
+	}
+	klog.V(3).Infof("(tc) updating horizontalPodAutoscaler for %q, replicas %d-%d", fni.Name, *want.Spec.MinReplicas, want.Spec.MaxReplicas)
+	return retryOnceOnError(func() error {
+		if hpa == nil {
+			// refresh to the latest version
+			if hpa, err = rc.getHorizontalPodAutoscalerV2(fni); err != nil {
+				return err
+			}
+		}
+		hpa = hpa.DeepCopy()
+		hpa.Labels = want.Labels
+		hpa.Spec = want.Spec
+		if _, err = rc.kclient.AutoscalingV2().HorizontalPodAutoscalers(fni.Namespace).Update(context.TODO(), hpa, metav1.UpdateOptions{}); err != nil {
+			hpa = nil
+		}
+		return err
+	})
+}
+
+// syncHorizontalPodAutoscalerV1 creates hpa for funcinst, or updates it if autoscaling of fndef is changed
+func (rc *Controller) syncHorizontalPodAutoscalerV1(fni *rfv1beta3.Funcinst, fndef *rfv1beta3.Funcdef) error {
+	hpa, err := rc.hpaV1Lister.HorizontalPodAutoscalers(fni.Namespace).Get(fni.Name)
+	if hpa == nil || k8sutil.IsResourceNotFoundError(err) {
+		klog.Infof("(tc) creating horizontalPodAutoscaler for %q", fni.Name)
+		rs, err := rc.getRuntimeReplciaSet(fni)
+		if err != nil {
+			return err
+		}
+		hpa = rc.horizontalPodAutoscalerV1(fni, fndef, rs.GetName())
+		if err = retryOnceOnError(func() error {
+			hpa, err = rc.kclient.AutoscalingV1().HorizontalPodAutoscalers(fni.Namespace).Create(context.TODO(), hpa, metav1.CreateOptions{})
+			if apierrors.IsAlreadyExists(err) {
+				hpa, err = rc.getHorizontalPodAutoscalerV1(fni)
+			}
+			return err
+		}); err == nil {
+			return nil
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	want := rc.horizontalPodAutoscalerV1(fni, fndef, hpa.Spec.ScaleTargetRef.Name)
+	if hpa.Labels[rfv1beta3.LabelSpecHash] == want.Labels[rfv1beta3.LabelSpecHash] {
+		return nil
+	}
+	klog.V(3).Infof("(tc) updating horizontalPodAutoscaler for %q, replicas %d-%d", fni.Name, *want.Spec.MinReplicas, want.Spec.MaxReplicas)
+	return retryOnceOnError(func() error {
+		if hpa == nil {
+			// refresh to the latest version
+			if hpa, err = rc.getHorizontalPodAutoscalerV1(fni); err != nil {
+				return err
+			}
+		}
+		hpa = hpa.DeepCopy()
+		hpa.Labels = want.Labels
+		hpa.Spec = want.Spec
+		if _, err = rc.kclient.AutoscalingV1().HorizontalPodAutoscalers(fni.Namespace).Update(context.TODO(), hpa, metav1.UpdateOptions{}); err != nil {
+			hpa = nil
+		}
+		return err
+	})
+}
+
+// deleteHorizontalPodAutoscaler removes hpa of funcinst if exists
+func (rc *Controller) deleteHorizontalPodAutoscaler(funcinst *rfv1beta3.Funcinst) (err error) {
+	if rc.hpaV2Lister != nil {
+		if _, err = rc.hpaV2Lister.HorizontalPodAutoscalers(funcinst.Namespace).Get(funcinst.Name); err == nil {
+			klog.Infof("(tc) deleting horizontalPodAutoscaler for %q", funcinst.Name)
+			err = rc.kclient.AutoscalingV2().HorizontalPodAutoscalers(funcinst.Namespace).Delete(context.TODO(), funcinst.Name, metav1.DeleteOptions{})
+		}
+	} else {
+		if _, err = rc.hpaV1Lister.HorizontalPodAutoscalers(funcinst.Namespace).Get(funcinst.Name); err == nil {
+			klog.Infof("(tc) deleting horizontalPodAutoscaler for %q", funcinst.Name)
+			err = rc.kclient.AutoscalingV1().HorizontalPodAutoscalers(funcinst.Namespace).Delete(context.TODO(), funcinst.Name, metav1.DeleteOptions{})
+		}
+	}
+	if k8sutil.IsResourceNotFoundError(err) {
+		return nil
+	}
+	return err
+}
+
+// defaults of HPA
+const (
+	defaultTargetCPU    int32 = 90
+	defaultTargetMemory int32 = 90
 )
 
+// hpaMinReplicas returns min replicas of hpa, at least one executor is kept
+func hpaMinReplicas(fndef *rfv1beta3.Funcdef) *int32 {
+	minReplicas := fndef.Spec.ProvisionedReplicas()
+	if minReplicas < 1 {
+		minReplicas = 1
+	}
+	return &minReplicas
+}
+
+// hpaTargets returns the target cpu and memory utilization of fndef, 0 means disabled
+func hpaTargets(fndef *rfv1beta3.Funcdef) (cpu, memory int32) {
+	cpu, memory = defaultTargetCPU, defaultTargetMemory
+	if as := fndef.Spec.Autoscaling; as != nil {
+		if as.TargetCPUUtilization != nil {
+			cpu = *as.TargetCPUUtilization
+		}
+		if as.TargetMemoryUtilization != nil {
+			memory = *as.TargetMemoryUtilization
+		}
+	}
+	return
+}
+
+func resourceMetricV2(name corev1.ResourceName, target int32) autoscalev2.MetricSpec {
+	return autoscalev2.MetricSpec{
+		Type: autoscalev2.ResourceMetricSourceType,
+		Resource: &autoscalev2.ResourceMetricSource{
+			Name: name,
+			Target: autoscalev2.MetricTarget{
+				Type:               autoscalev2.UtilizationMetricType,
+				AverageUtilization: &target,
+			},
+		},
+	}
+}
+
 func (rc *Controller) horizontalPodAutoscalerV2(funcinst *rfv1beta3.Funcinst, fndef *rfv1beta3.Funcdef, rsName string) *autoscalev2.HorizontalPodAutoscaler {
 	as := &autoscalev2.HorizontalPodAutoscaler{
 		ObjectMeta: metav1.ObjectMeta{
@@ -41,30 +192,8 @@ func (rc *Controller) horizontalPodAutoscalerV2(funcinst *rfv1beta3.Funcinst, fn
 			Labels:    rfutil.ExecutorLabels(funcinst),
 		},
 		Spec: autoscalev2.HorizontalPodAutoscalerSpec{
-			MinReplicas: &minReplicas,
+			MinReplicas: hpaMinReplicas(fndef),
 			MaxReplicas: fndef.Spec.MaxReplicas,
-			Metrics: []autoscalev2.MetricSpec{
-				{
-					Type: autoscalev2.ResourceMetricSourceType,
-					Resource: &autoscalev2.ResourceMetricSource{
-						Name: "cpu",
-						Target: autoscalev2.MetricTarget{
-							Type:               autoscalev2.UtilizationMetricType,
-							AverageUtilization: &targetCPU,
-						},
-					},
-				},
-				{
-					Type: autoscalev2.ResourceMetricSourceType,
-					Resource: &autoscalev2.ResourceMetricSource{
-						Name: "memory",
-						Target: autoscalev2.MetricTarget{
-							Type:               autoscalev2.UtilizationMetricType,
-							AverageUtilization: &targetMemory,
-						},
-					},
-				},
-			},
 			ScaleTargetRef: autoscalev2.CrossVersionObjectReference{
 				Kind:       "ReplicaSet",
 				Name:       rsName,
@@ -72,6 +201,20 @@ func (rc *Controller) horizontalPodAutoscalerV2(funcinst *rfv1beta3.Funcinst, fn
 			},
 		},
 	}
+	cpu, memory := hpaTargets(fndef)
+	if cpu > 0 {
+		as.Spec.Metrics = append(as.Spec.Metrics, resourceMetricV2(corev1.ResourceCPU, cpu))
+	}
+	if memory > 0 {
+		as.Spec.Metrics = append(as.Spec.Metrics, resourceMetricV2(corev1.ResourceMemory, memory))
+	}
+	if fndef.Spec.Autoscaling != nil {
+		autoscaling := fndef.Spec.Autoscaling.DeepCopy()
+		as.Spec.Metrics = append(as.Spec.Metrics, autoscaling.Metrics...)
+		as.Spec.Behavior = autoscaling.Behavior
+	}
+	// the spec may be defaulted by apiserver, use hash to detect changes
+	as.Labels[rfv1beta3.LabelSpecHash] = rfutil.GetMD5Hash(as.Spec)
 	// set owner
 	ownerRef := funcinst.AsOwner()
 	ownerRef.Controller = &isController
@@ -87,9 +230,8 @@ func (rc *Controller) horizontalPodAutoscalerV1(funcinst *rfv1beta3.Funcinst, fn
 			Labels:    rfutil.ExecutorLabels(funcinst),
 		},
 		Spec: autoscalev1.HorizontalPodAutoscalerSpec{
-			MinReplicas:                    &minReplicas,
-			MaxReplicas:                    fndef.Spec.MaxReplicas,
-			TargetCPUUtilizationPercentage: &targetCPU,
+			MinReplicas: hpaMinReplicas(fndef),
+			MaxReplicas: fndef.Spec.MaxReplicas,
 			ScaleTargetRef: autoscalev1.CrossVersionObjectReference{
 				Kind:       "ReplicaSet",
 				Name:       rsName,
@@ -97,6 +239,11 @@ func (rc *Controller) horizontalPodAutoscalerV1(funcinst *rfv1beta3.Funcinst, fn
 			},
 		},
 	}
+	if cpu, _ := hpaTargets(fndef); cpu > 0 {
+		as.Spec.TargetCPUUtilizationPercentage = &cpu
+	}
+	// the spec may be defaulted by apiserver, use hash to detect changes
+	as.Labels[rfv1beta3.LabelSpecHash] = rfutil.GetMD5Hash(as.Spec)
 	// set owner
 	ownerRef := funcinst.AsOwner()
 	ownerRef.Controller = &isController
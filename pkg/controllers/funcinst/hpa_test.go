@@ -0,0 +1,73 @@
+package funcinst
+
+import (
+	"testing"
+
+	autoscalev2 "k8s.io/api/autoscaling/v2"
+
+	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
+)
+
+func TestHorizontalPodAutoscalerV2(t *testing.T) {
+	var zero int32
+	cases := []struct {
+		spec        rfv1beta3.FuncdefSpec
+		minReplicas int32
+		metrics     []string
+	}{
+		{
+			spec:        rfv1beta3.FuncdefSpec{MaxReplicas: 4},
+			minReplicas: 1,
+			metrics:     []string{"cpu", "memory"},
+		},
+		{
+			spec: rfv1beta3.FuncdefSpec{MinReplicas: 2, MaxReplicas: 4, Autoscaling: &rfv1beta3.Autoscaling{
+				TargetMemoryUtilization: &zero,
+				Metrics: []autoscalev2.MetricSpec{{
+					Type:     autoscalev2.ExternalMetricSourceType,
+					External: &autoscalev2.ExternalMetricSource{Metric: autoscalev2.MetricIdentifier{Name: "queue_depth"}},
+				}},
+			}},
+			minReplicas: 2,
+			metrics:     []string{"cpu", "queue_depth"},
+		},
+		{
+			spec:        rfv1beta3.FuncdefSpec{MinReplicas: 1, MaxReplicas: 4, ProvisionedConcurrency: 3},
+			minReplicas: 3,
+			metrics:     []string{"cpu", "memory"},
+		},
+	}
+
+	rc := &Controller{}
+	hashes := map[string]bool{}
+	for i, c := range cases {
+		fni := &rfv1beta3.Funcinst{}
+		hpa := rc.horizontalPodAutoscalerV2(fni, &rfv1beta3.Funcdef{Spec: c.spec}, "rs")
+		if *hpa.Spec.MinReplicas != c.minReplicas {
+			t.Errorf("#%d minReplicas = %d, want %d", i, *hpa.Spec.MinReplicas, c.minReplicas)
+		}
+		var metrics []string
+		for _, m := range hpa.Spec.Metrics {
+			switch m.Type {
+			case autoscalev2.ResourceMetricSourceType:
+				metrics = append(metrics, string(m.Resource.Name))
+			case autoscalev2.ExternalMetricSourceType:
+				metrics = append(metrics, m.External.Metric.Name)
+			}
+		}
+		if len(metrics) != len(c.metrics) {
+			t.Errorf("#%d metrics = %v, want %v", i, metrics, c.metrics)
+			continue
+		}
+		for j := range metrics {
+			if metrics[j] != c.metrics[j] {
+				t.Errorf("#%d metrics = %v, want %v", i, metrics, c.metrics)
+				break
+			}
+		}
+		hashes[hpa.Labels[rfv1beta3.LabelSpecHash]] = true
+	}
+	if len(hashes) != len(cases) {
+		t.Errorf("spec hash should change with autoscaling")
+	}
+}
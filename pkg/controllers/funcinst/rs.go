@@ -42,7 +42,7 @@ func (rc *Controller) getRuntimeReplciaSet(funcinst *rfv1beta3.Funcinst) (*appsv
 func (rc *Controller) prepareRuntimeReplicaSet(funcinst *rfv1beta3.Funcinst, fndef *rfv1beta3.Funcdef, xenv *rfv1beta3.Xenv) (rs *appsv1.ReplicaSet, pod *corev1.Pod, err error) {
 
 	var dep *appsv1.Deployment
-	if xenv.Spec.PoolSize > 0 && !(fndef.Spec.MinReplicas > 0) {
+	if xenv.Spec.PoolSize > 0 && !(fndef.Spec.ProvisionedReplicas() > 0) {
 		// relabel a pod if xenv has a pool
 		dep, err = runtime.GetXenvPoolDeployment(rc.deploymentLister, xenv)
 		if dep != nil {
@@ -80,8 +80,8 @@ func (rc *Controller) prepareRuntimeReplicaSet(funcinst *rfv1beta3.Funcinst, fnd
 
 	// creating a replicas from template
 	rs = rc.replicaSetFromTemplate(funcinst, dep)
-	if fndef.Spec.MinReplicas > 0 {
-		rs.Spec.Replicas = &fndef.Spec.MinReplicas
+	if provisioned := fndef.Spec.ProvisionedReplicas(); provisioned > 0 {
+		rs.Spec.Replicas = &provisioned
 	}
 	err = retryOnceOnError(func() error {
 		rs, err = rc.kclient.AppsV1().ReplicaSets(funcinst.Namespace).Create(context.TODO(), rs, metav1.CreateOptions{})
@@ -129,3 +129,69 @@ func (rc *Controller) replicaSetFromTemplate(funcinst *rfv1beta3.Funcinst, dep *
 
 	return rs
 }
+
+// scaleToZero scales executors of idle funcinst to zero, the funcinst is kept to serve later requests
+func (rc *Controller) scaleToZero(funcinst *rfv1beta3.Funcinst) error {
+	rs, err := rc.getRuntimeReplciaSet(funcinst)
+	if err != nil {
+		return err
+	}
+	if rs != nil {
+		if err := rc.scaleRuntimeReplicaSet(rs, 0); err != nil {
+			return err
+		}
+	}
+	_, err = rc.updateStatus(funcinst, *funcinst.Status.DeepCopy().SetScaledToZero())
+	return err
+}
+
+// wakeRuntimeReplicaSet scales up executors of funcinst from zero
+func (rc *Controller) wakeRuntimeReplicaSet(funcinst *rfv1beta3.Funcinst, fndef *rfv1beta3.Funcdef) error {
+	rs, err := rc.getRuntimeReplciaSet(funcinst)
+	if err != nil || rs == nil {
+		// rs will be created if not exists
+		return err
+	}
+	if rs.Spec.Replicas != nil && *rs.Spec.Replicas > 0 {
+		return nil
+	}
+	replicas := initReplicas
+	if provisioned := fndef.Spec.ProvisionedReplicas(); provisioned > replicas {
+		replicas = provisioned
+	}
+	klog.V(2).Infof("(tc) waking %q, scaling rs %q to %d", funcinst.Name, rs.Name, replicas)
+	return rc.scaleRuntimeReplicaSet(rs, replicas)
+}
+
+// syncProvisionedReplicas keeps provisioned executors of funcinst ready,
+// replicas of funcinst which is not autoscaled are reconciled to the provisioned number
+func (rc *Controller) syncProvisionedReplicas(funcinst *rfv1beta3.Funcinst, fndef *rfv1beta3.Funcdef, autoscaled bool) error {
+	provisioned := fndef.Spec.ProvisionedReplicas()
+	if provisioned == 0 && autoscaled {
+		return nil
+	}
+	rs, err := rc.getRuntimeReplciaSetFromCache(funcinst)
+	if err != nil || rs == nil {
+		return err
+	}
+	var current int32 = 1
+	if rs.Spec.Replicas != nil {
+		current = *rs.Spec.Replicas
+	}
+	replicas := provisioned
+	if replicas < initReplicas {
+		replicas = initReplicas
+	}
+	if current == replicas || (autoscaled && current > replicas) {
+		return nil
+	}
+	klog.V(3).Infof("(tc) scaling provisioned executors of %q from %d -> %d", funcinst.Name, current, replicas)
+	return rc.scaleRuntimeReplicaSet(rs, replicas)
+}
+
+func (rc *Controller) scaleRuntimeReplicaSet(rs *appsv1.ReplicaSet, replicas int32) error {
+	rs = rs.DeepCopy()
+	rs.Spec.Replicas = &replicas
+	_, err := rc.kclient.AppsV1().ReplicaSets(rs.Namespace).Update(context.TODO(), rs, metav1.UpdateOptions{})
+	return err
+}
@@ -0,0 +1,75 @@
+package funcinst
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
+	"github.com/refunc/refunc/pkg/utils/rfutil"
+)
+
+func TestController_wakeRuntimeReplicaSet(t *testing.T) {
+	fni := &rfv1beta3.Funcinst{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "fn-1", UID: "uid"}}
+
+	tests := []struct {
+		name        string
+		replicas    int32
+		provisioned int32
+		want        int32
+	}{
+		{"from zero", 0, 0, initReplicas},
+		{"from zero to provisioned", 0, 3, 3},
+		{"already awake", 2, 0, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replicas := tt.replicas
+			kclient := kubefake.NewSimpleClientset(&appsv1.ReplicaSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:       "ns",
+					Name:            "fn-1-rs",
+					Labels:          rfutil.ExecutorLabels(fni),
+					OwnerReferences: []metav1.OwnerReference{*fni.AsOwner()},
+				},
+				Spec: appsv1.ReplicaSetSpec{Replicas: &replicas},
+			})
+			rc := &Controller{kclient: kclient}
+			fndef := &rfv1beta3.Funcdef{Spec: rfv1beta3.FuncdefSpec{MinReplicas: tt.provisioned}}
+
+			if err := rc.wakeRuntimeReplicaSet(fni, fndef); err != nil {
+				t.Fatal(err)
+			}
+			rs, err := kclient.AppsV1().ReplicaSets("ns").Get(context.TODO(), "fn-1-rs", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *rs.Spec.Replicas != tt.want {
+				t.Errorf("replicas = %d, want %d", *rs.Spec.Replicas, tt.want)
+			}
+		})
+	}
+}
+
+func TestFuncinstStatus_wake(t *testing.T) {
+	var status rfv1beta3.FuncinstStatus
+	status.SetActiveCondition("PodInitialized", "At least one pod of funcinst was initialized")
+
+	status.SetScaledToZero()
+	if !status.IsScaledToZero() || status.IsWakeRequested() {
+		t.Fatalf("SetScaledToZero() = %+v", status.ActiveCondition())
+	}
+	status.SetWakeRequested()
+	if status.IsScaledToZero() || !status.IsWakeRequested() {
+		t.Fatalf("SetWakeRequested() = %+v", status.ActiveCondition())
+	}
+	// pods of woken funcinst are initialized
+	status.SetActiveCondition("PodInitialized", "At least one pod of funcinst was initialized")
+	if status.IsScaledToZero() || status.IsWakeRequested() || status.ActiveCondition().Status != corev1.ConditionTrue {
+		t.Errorf("SetActiveCondition() = %+v", status.ActiveCondition())
+	}
+}
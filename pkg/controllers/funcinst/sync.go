@@ -1,14 +1,12 @@
 package funcinst
 
 import (
-	"context"
 	"errors"
 	"fmt"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog"
 
@@ -96,6 +94,16 @@ func (rc *Controller) sync(key string) error {
 		return err
 	}
 
+	if fni.Status.IsScaledToZero() {
+		// waiting for requests
+		return nil
+	}
+	if fni.Status.IsWakeRequested() {
+		if err := rc.wakeRuntimeReplicaSet(fni, fndef); err != nil {
+			return err
+		}
+	}
+
 	// lising related pods
 	var pods []*corev1.Pod
 	// get pods from local cache, this may not be the latest version
@@ -206,78 +214,39 @@ func (rc *Controller) sync(key string) error {
 		return fmt.Errorf("tc: no active pod found for %q", key)
 	}
 
+	// report provisioned executors
+	provisioned := int(fndef.Spec.ProvisionedReplicas())
+	if provisioned > nActive {
+		provisioned = nActive
+	}
+	if fni.Status.Provisioned != provisioned {
+		status := fni.Status.DeepCopy()
+		status.Provisioned = provisioned
+		if fni, err = rc.updateStatus(fni, *status); err != nil {
+			return err
+		}
+	}
+
 	// check replicas
-	if fndef.Spec.MaxReplicas > 1 && fndef.Spec.MaxReplicas > fndef.Spec.MinReplicas {
+	autoscaled := fndef.Spec.MaxReplicas > 1 && fndef.Spec.MaxReplicas > fndef.Spec.ProvisionedReplicas()
+	if err := rc.syncProvisionedReplicas(fni, fndef, autoscaled); err != nil {
+		return err
+	}
+
+	if rc.scaledByConcurrency(fndef) {
+		// replicas are managed by concurrency scaler
+		return rc.deleteHorizontalPodAutoscaler(fni)
+	}
+
+	if autoscaled {
 		if rc.hpaV2Lister != nil {
-			hpa, err := rc.hpaV2Lister.HorizontalPodAutoscalers(fni.Namespace).Get(fni.Name)
-			if hpa == nil || k8sutil.IsResourceNotFoundError(err) {
-				klog.Infof("(tc) creating horizontalPodAutoscaler for %q", fni.Name)
-				rs, err := rc.getRuntimeReplciaSet(fni)
-				if err != nil {
-					return err
-				}
-				hpa = rc.horizontalPodAutoscalerV2(fni, fndef, rs.GetName())
-				if err = retryOnceOnError(func() error {
-					hpa, err = rc.kclient.AutoscalingV2().HorizontalPodAutoscalers(fni.Namespace).Create(context.TODO(), hpa, metav1.CreateOptions{})
-					if apierrors.IsAlreadyExists(err) {
-						hpa, err = rc.getHorizontalPodAutoscalerV2(fni)
-					}
-					return err
-				}); err == nil {
-					return nil
-				}
-			}
-			if err != nil {
-				return err
-			}
-			if hpa.Spec.MaxReplicas != fndef.Spec.MaxReplicas {
-				klog.V(3).Infof("(tc) updating horizontalPodAutoscaler for %q, from %d -> %d", fni.Name, hpa.Spec.MaxReplicas, fndef.Spec.MaxReplicas)
-				return retryOnceOnError(func() error {
-					hpa.Spec.MaxReplicas = fndef.Spec.MaxReplicas
-					hpa, err = rc.kclient.AutoscalingV2().HorizontalPodAutoscalers(fni.Namespace).Update(context.TODO(), hpa, metav1.UpdateOptions{})
-					if err != nil {
-						hpa, err = rc.getHorizontalPodAutoscalerV2(fni)
-					}
-					return err
-				})
-			}
-		} else {
-			hpa, err := rc.hpaV1Lister.HorizontalPodAutoscalers(fni.Namespace).Get(fni.Name)
-			if hpa == nil || k8sutil.IsResourceNotFoundError(err) {
-				klog.Infof("(tc) creating horizontalPodAutoscaler for %q", fni.Name)
-				rs, err := rc.getRuntimeReplciaSet(fni)
-				if err != nil {
-					return err
-				}
-				hpa = rc.horizontalPodAutoscalerV1(fni, fndef, rs.GetName())
-				if err = retryOnceOnError(func() error {
-					hpa, err = rc.kclient.AutoscalingV1().HorizontalPodAutoscalers(fni.Namespace).Create(context.TODO(), hpa, metav1.CreateOptions{})
-					if apierrors.IsAlreadyExists(err) {
-						hpa, err = rc.getHorizontalPodAutoscalerV1(fni)
-					}
-					return err
-				}); err == nil {
-					return nil
-				}
-			}
-			if err != nil {
-				return err
-			}
-			if hpa.Spec.MaxReplicas != fndef.Spec.MaxReplicas {
-				klog.V(3).Infof("(tc) updating horizontalPodAutoscaler for %q, from %d -> %d", fni.Name, hpa.Spec.MaxReplicas, fndef.Spec.MaxReplicas)
-				return retryOnceOnError(func() error {
-					hpa.Spec.MaxReplicas = fndef.Spec.MaxReplicas
-					hpa, err = rc.kclient.AutoscalingV1().HorizontalPodAutoscalers(fni.Namespace).Update(context.TODO(), hpa, metav1.UpdateOptions{})
-					if err != nil {
-						hpa, err = rc.getHorizontalPodAutoscalerV1(fni)
-					}
-					return err
-				})
-			}
+			return rc.syncHorizontalPodAutoscalerV2(fni, fndef)
 		}
+		return rc.syncHorizontalPodAutoscalerV1(fni, fndef)
 	}
 
-	return nil
+	// replicas are not scaled
+	return rc.deleteHorizontalPodAutoscaler(fni)
 }
 
 func (rc *Controller) handleChange(obj interface{}) {
@@ -62,6 +62,15 @@ type InvokeResponse struct {
 	ContentType string `json:"ContentType,omitempty"`
 }
 
+// InflightMetrics is reported periodically by each executor of a funcinst
+type InflightMetrics struct {
+	Pod string `json:"pod"`
+	// Inflight is the number of requests being processed
+	Inflight int `json:"inflight"`
+	// Concurrency is the number of requests can be processed in parallel
+	Concurrency int `json:"concurrency"`
+}
+
 // ErrorMessage wraps error information during a invocation
 type ErrorMessage struct {
 	Message    string        `json:"errorMessage"`
@@ -2,6 +2,7 @@ package operators
 
 import (
 	"strings"
+	"sync"
 
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
@@ -29,6 +30,9 @@ type BaseOperator struct {
 	FuncdefLister   rflistersv1.FuncdefLister
 	TriggerLister   rflistersv1.TriggerLister
 	WantedInformers []cache.InformerSynced
+
+	// error rates of canaries, keyed by trigger
+	canaries sync.Map
 }
 
 // NewBaseOperator creates a new refunc router from config
@@ -16,6 +16,7 @@ import (
 
 	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
 	"github.com/refunc/refunc/pkg/env"
+	operators "github.com/refunc/refunc/pkg/operators"
 	"github.com/refunc/refunc/pkg/utils/rfutil"
 )
 
@@ -83,7 +84,7 @@ func (r *Operator) setProvisionedInstance(trigger *rfv1beta3.Trigger) (*rfv1beta
 		klog.Errorf("(fnio) set provisioned instance can't resolve funcdef %v", err)
 		return nil, err
 	}
-	if fndef.Spec.MinReplicas > 0 {
+	if fndef.Spec.ProvisionedReplicas() > 0 {
 		fni, err := r.GetFuncInstance(trigger)
 		if err != nil {
 			klog.Errorf("(fnio) set provisioned instance error %v", err)
@@ -189,6 +190,21 @@ func (r *Operator) GetFuncInstance(trigger *rfv1beta3.Trigger) (*rfv1beta3.Funci
 	return fni, nil
 }
 
+// WakeFuncInstance requests to scale up the funcinst which is scaled to zero
+func (r *Operator) WakeFuncInstance(fni *rfv1beta3.Funcinst) error {
+	fni, err := r.FuncinstLister.Funcinsts(fni.Namespace).Get(fni.Name)
+	if err != nil {
+		return err
+	}
+	if !fni.Status.IsScaledToZero() {
+		// already waked by other requests or operators
+		return nil
+	}
+	klog.V(3).Infof("(fnio) waking %s/%s", fni.Namespace, fni.Name)
+	_, err = rfutil.UpdateFuncinstStatus(r.RefuncClient.RefuncV1beta3().Funcinsts(fni.Namespace), fni.DeepCopy(), *fni.Status.DeepCopy().SetWakeRequested())
+	return err
+}
+
 // Tap funcinst keeps it live
 func (r *Operator) Tap(key string) {
 	r.tappings.Update(key)
@@ -213,6 +229,15 @@ func (r *Operator) indexOf(fni *rfv1beta3.Funcinst) {
 	}
 }
 
+// GetFuncMeta returns metadata of the function behind trigger
+func (r *Operator) GetFuncMeta(trigger *rfv1beta3.Trigger) (*operators.FuncMeta, error) {
+	fndef, err := r.ResolveFuncdef(trigger)
+	if err != nil {
+		return nil, err
+	}
+	return operators.NewFuncMeta(fndef, trigger, r.FuncinstLister)
+}
+
 func (r *Operator) getInstForTrigger(key string) (fni *rfv1beta3.Funcinst, has bool) {
 	if val, ok := r.fninsts4Trigger.Load(key); ok {
 		cache := val.(instCacheFactory)()
@@ -0,0 +1,64 @@
+package funcinsts
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+
+	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
+	rffake "github.com/refunc/refunc/pkg/generated/clientset/versioned/fake"
+	rflistersv1 "github.com/refunc/refunc/pkg/generated/listers/refunc/v1beta3"
+	"github.com/refunc/refunc/pkg/operators"
+)
+
+func TestOperator_WakeFuncInstance(t *testing.T) {
+	newFuncinst := func(name string, scaledToZero bool) *rfv1beta3.Funcinst {
+		fni := &rfv1beta3.Funcinst{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name}}
+		fni.Status.SetActiveCondition("PodInitialized", "At least one pod of funcinst was initialized")
+		if scaledToZero {
+			fni.Status.SetScaledToZero()
+		}
+		return fni
+	}
+	idle, active := newFuncinst("idle", true), newFuncinst("active", false)
+
+	fnis := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	fnis.Add(idle)   // nolint:errcheck
+	fnis.Add(active) // nolint:errcheck
+
+	// records status updates
+	updated := make(map[string]*rfv1beta3.Funcinst)
+	rclient := rffake.NewSimpleClientset()
+	rclient.PrependReactor("update", "funcinsts", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		fni := action.(k8stesting.UpdateAction).GetObject().(*rfv1beta3.Funcinst)
+		updated[fni.Name] = fni
+		return true, fni, nil
+	})
+	r := &Operator{
+		BaseOperator:   &operators.BaseOperator{RefuncClient: rclient},
+		FuncinstLister: rflistersv1.NewFuncinstLister(fnis),
+	}
+
+	tests := []struct {
+		fni  *rfv1beta3.Funcinst
+		want bool
+	}{
+		{idle, true},
+		{active, false},
+	}
+	for _, tt := range tests {
+		if err := r.WakeFuncInstance(tt.fni); err != nil {
+			t.Fatal(err)
+		}
+		got, ok := updated[tt.fni.Name]
+		if ok != tt.want {
+			t.Fatalf("%s updated = %v, want %v", tt.fni.Name, ok, tt.want)
+		}
+		if ok && !got.Status.IsWakeRequested() {
+			t.Errorf("%s is not requested to wake, %+v", tt.fni.Name, got.Status.ActiveCondition())
+		}
+	}
+}
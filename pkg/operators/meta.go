@@ -0,0 +1,113 @@
+package operators
+
+import (
+	"encoding/json"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/labels"
+
+	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
+	rflistersv1 "github.com/refunc/refunc/pkg/generated/listers/refunc/v1beta3"
+	"github.com/refunc/refunc/pkg/utils/rfutil"
+)
+
+// FuncMeta is the document served by _meta endpoints of a function
+//
+//	{
+//	  "namespace": "default",
+//	  "name": "hello",
+//	  "hash": "...",
+//	  "version": "...",
+//	  "runtime": "python3.7",
+//	  "timeout": 9,
+//	  "minReplicas": 0,
+//	  "maxReplicas": 1,
+//	  "meta": {...},
+//	  "trigger": {"name": "hello", "type": "httptrigger", "config": {"http": {...}}},
+//	  "instances": [{"name": "...", "hash": "...", "active": 1, "conditions": [...]}]
+//	}
+type FuncMeta struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Hash of function's code
+	Hash string `json:"hash"`
+	// Version of function, it's lambda version if set otherwise the hash
+	Version string `json:"version"`
+	// Runtime is the name of xenv
+	Runtime string `json:"runtime,omitempty"`
+	// Timeout in seconds of an invocation
+	Timeout     int   `json:"timeout,omitempty"`
+	MinReplicas int32 `json:"minReplicas"`
+	MaxReplicas int32 `json:"maxReplicas"`
+	// ProvisionedConcurrency is the number of executors kept ready ahead of traffic
+	ProvisionedConcurrency int32 `json:"provisionedConcurrency,omitempty"`
+	// Meta is the raw meta of Funcdef
+	Meta json.RawMessage `json:"meta,omitempty"`
+
+	Trigger   FuncMetaTrigger    `json:"trigger"`
+	Instances []FuncMetaInstance `json:"instances"`
+}
+
+// FuncMetaTrigger is the trigger serving a _meta request
+type FuncMetaTrigger struct {
+	Name   string                  `json:"name"`
+	Type   string                  `json:"type"`
+	Config rfv1beta3.TriggerConfig `json:"config"`
+}
+
+// FuncMetaInstance is the status of a funcinst of the function
+type FuncMetaInstance struct {
+	Name        string                        `json:"name"`
+	Hash        string                        `json:"hash,omitempty"`
+	Active      int                           `json:"active"`
+	Provisioned int                           `json:"provisioned,omitempty"`
+	Conditions  []rfv1beta3.FuncinstCondition `json:"conditions,omitempty"`
+}
+
+// NewFuncMeta returns metadata of fndef served by trigger
+func NewFuncMeta(
+	fndef *rfv1beta3.Funcdef,
+	trigger *rfv1beta3.Trigger,
+	fniLister rflistersv1.FuncinstLister,
+) (*FuncMeta, error) {
+	meta := &FuncMeta{
+		Namespace:   fndef.Namespace,
+		Name:        fndef.Name,
+		Hash:        rfutil.GetHash(fndef),
+		Version:     rfutil.GetFunctionVersion(fndef),
+		MinReplicas: fndef.Spec.MinReplicas,
+		MaxReplicas: fndef.Spec.MaxReplicas,
+		Meta:        fndef.Spec.Meta,
+		// executors kept ready ahead of traffic
+		ProvisionedConcurrency: fndef.Spec.ProvisionedConcurrency,
+		Trigger: FuncMetaTrigger{
+			Name:   trigger.Name,
+			Type:   trigger.Spec.Type,
+			Config: trigger.Spec.TriggerConfig,
+		},
+		Instances: []FuncMetaInstance{},
+	}
+	if fndef.Spec.Runtime != nil {
+		meta.Runtime = fndef.Spec.Runtime.Name
+		meta.Timeout = fndef.Spec.Runtime.Timeout
+	}
+
+	fnis, err := fniLister.Funcinsts(fndef.Namespace).List(labels.SelectorFromSet(labels.Set{
+		rfv1beta3.LabelName: fndef.Name,
+	}))
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(fnis, func(i, j int) bool { return fnis[i].Name < fnis[j].Name })
+	for _, fni := range fnis {
+		meta.Instances = append(meta.Instances, FuncMetaInstance{
+			Name:        fni.Name,
+			Hash:        fni.Labels[rfv1beta3.LabelHash],
+			Active:      fni.Status.Active,
+			Provisioned: fni.Status.Provisioned,
+			Conditions:  fni.Status.Conditions,
+		})
+	}
+
+	return meta, nil
+}
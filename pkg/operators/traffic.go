@@ -0,0 +1,173 @@
+package operators
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog"
+
+	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
+	"github.com/refunc/refunc/pkg/utils/rfutil"
+)
+
+// gatewayTriggerType is the type of triggers serving funcdeves at endpoint <ns>/<name>,
+// requests of canary are forwarded to its endpoint.
+const gatewayTriggerType = "eventgateway"
+
+// defaults of TrafficRollback
+const (
+	DefaultMaxErrorRate   = 10
+	DefaultMinRequests    = 20
+	DefaultRollbackWindow = 60 * time.Second
+)
+
+// RouteFuncdef returns the version of funcdef that serves a request of trigger,
+// a canary is picked according to the weight of trigger's traffic split.
+func (r *BaseOperator) RouteFuncdef(trigger *rfv1beta3.Trigger) (*rfv1beta3.Funcdef, error) {
+	split := trigger.Spec.Traffic
+	if split == nil || split.Weight <= 0 || split.FuncName == "" || split.FuncName == trigger.Spec.FuncName {
+		return r.ResolveFuncdef(trigger)
+	}
+	if split.Weight < 100 && rand.Intn(100) >= split.Weight {
+		return r.ResolveFuncdef(trigger)
+	}
+
+	canary, err := r.FuncdefLister.Funcdeves(trigger.Namespace).Get(split.FuncName)
+	if err != nil {
+		klog.V(3).Infof("(o) %s/%s canary %q is not resolved, %v", trigger.Namespace, trigger.Name, split.FuncName, err)
+		return r.ResolveFuncdef(trigger)
+	}
+	hash := rfutil.GetHash(canary)
+	if split.Hash != "" && split.Hash != hash && split.Hash != canary.Spec.Hash {
+		// canary is not deployed yet
+		return r.ResolveFuncdef(trigger)
+	}
+	if trigger.Status.IsRolledBack(split.FuncName, hash) {
+		return r.ResolveFuncdef(trigger)
+	}
+	if !r.hasEndpoint(canary) {
+		klog.V(3).Infof("(o) %s/%s canary %q has no %s trigger", trigger.Namespace, trigger.Name, split.FuncName, gatewayTriggerType)
+		return r.ResolveFuncdef(trigger)
+	}
+	return canary, nil
+}
+
+// hasEndpoint returns true if fndef is served at its own endpoint
+func (r *BaseOperator) hasEndpoint(fndef *rfv1beta3.Funcdef) bool {
+	trs, err := r.TriggerLister.Triggers(fndef.Namespace).List(labels.Everything())
+	if err != nil {
+		return false
+	}
+	for _, tr := range trs {
+		if tr.Spec.Type != gatewayTriggerType {
+			continue
+		}
+		name := tr.Spec.FuncName
+		if tr.Spec.Event != nil && tr.Spec.Event.Alias != "" {
+			name = tr.Spec.Event.Alias
+		}
+		if name == fndef.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// ObserveFuncdef records the result of a request served by fndef,
+// the canary of trigger is rolled back once its error rate exceeds the limit.
+func (r *BaseOperator) ObserveFuncdef(trigger *rfv1beta3.Trigger, fndef *rfv1beta3.Funcdef, failed bool) {
+	if !trigger.IsCanary(fndef) || trigger.Spec.Traffic.Rollback == nil {
+		return
+	}
+
+	key := trigger.Namespace + "/" + trigger.Name
+	version := fndef.Name + "@" + rfutil.GetHash(fndef)
+	val, _ := r.canaries.LoadOrStore(key, new(canaryStats))
+	stats := val.(*canaryStats)
+	total, errs, exceeded := stats.add(version, trigger.Spec.Traffic.Rollback, failed, time.Now())
+	if !exceeded {
+		return
+	}
+
+	msg := fmt.Sprintf("Canary %q failed %d of %d requests", fndef.Name, errs, total)
+	klog.Warningf("(o) %s rolling back, %s", key, msg)
+	go r.rollbackCanary(trigger.Namespace, trigger.Name, fndef, msg)
+}
+
+func (r *BaseOperator) rollbackCanary(ns, name string, fndef *rfv1beta3.Funcdef, msg string) {
+	hash := rfutil.GetHash(fndef)
+	triggers := r.RefuncClient.RefuncV1beta3().Triggers(ns)
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cur, err := triggers.Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if cur.Status.IsRolledBack(fndef.Name, hash) {
+			return nil
+		}
+		cur.Status.RolledBack = &rfv1beta3.TrafficRollbackStatus{
+			FuncName: fndef.Name,
+			Hash:     hash,
+			Time:     metav1.Now(),
+			Message:  msg,
+		}
+		_, err = triggers.UpdateStatus(context.TODO(), cur, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		klog.Errorf("(o) %s/%s failed to roll back canary, %v", ns, name, err)
+	}
+}
+
+// canaryStats counts requests of a canary in a fixed window
+type canaryStats struct {
+	sync.Mutex
+
+	version    string
+	start      time.Time
+	total      int
+	errors     int
+	rolledBack bool
+}
+
+// add records a request, exceeded is true only once when error rate of the window exceeds the limit
+func (cs *canaryStats) add(version string, policy *rfv1beta3.TrafficRollback, failed bool, now time.Time) (total, errors int, exceeded bool) {
+	maxErrorRate, minRequests, window := DefaultMaxErrorRate, DefaultMinRequests, DefaultRollbackWindow
+	if policy.MaxErrorRate > 0 {
+		maxErrorRate = policy.MaxErrorRate
+	}
+	if policy.MinRequests > 0 {
+		minRequests = policy.MinRequests
+	}
+	if policy.WindowSeconds > 0 {
+		window = time.Duration(policy.WindowSeconds) * time.Second
+	}
+
+	cs.Lock()
+	defer cs.Unlock()
+	if cs.version != version {
+		// canary is changed
+		cs.version, cs.start, cs.total, cs.errors, cs.rolledBack = version, now, 0, 0, false
+	}
+	if cs.rolledBack {
+		return cs.total, cs.errors, false
+	}
+	if now.Sub(cs.start) > window {
+		cs.start, cs.total, cs.errors = now, 0, 0
+	}
+	cs.total++
+	if failed {
+		cs.errors++
+	}
+	if cs.total >= minRequests && cs.errors*100 > cs.total*maxErrorRate {
+		cs.rolledBack = true
+		return cs.total, cs.errors, true
+	}
+	return cs.total, cs.errors, false
+}
@@ -0,0 +1,88 @@
+package operators
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
+	rflistersv1 "github.com/refunc/refunc/pkg/generated/listers/refunc/v1beta3"
+)
+
+func TestCanaryStats(t *testing.T) {
+	policy := &rfv1beta3.TrafficRollback{MaxErrorRate: 20, MinRequests: 5, WindowSeconds: 10}
+	now := time.Now()
+
+	cases := []struct {
+		version  string
+		failed   bool
+		after    time.Duration
+		exceeded bool
+	}{
+		{"v2", true, 0, false},
+		{"v2", true, time.Second, false},
+		{"v2", false, time.Second, false},
+		// window is reset
+		{"v2", true, 11 * time.Second, false},
+		{"v2", false, 12 * time.Second, false},
+		{"v2", false, 12 * time.Second, false},
+		{"v2", false, 13 * time.Second, false},
+		{"v2", true, 13 * time.Second, true},
+		// rolled back only once
+		{"v2", true, 14 * time.Second, false},
+		// canary is changed
+		{"v3", true, 15 * time.Second, false},
+	}
+
+	var cs canaryStats
+	for i, c := range cases {
+		if _, _, exceeded := cs.add(c.version, policy, c.failed, now.Add(c.after)); exceeded != c.exceeded {
+			t.Errorf("#%d exceeded = %v, want %v", i, exceeded, c.exceeded)
+		}
+	}
+	if total, errs, _ := cs.add("v3", policy, false, now.Add(16*time.Second)); total != 2 || errs != 1 {
+		t.Errorf("got %d/%d, want 1/2", errs, total)
+	}
+}
+
+func TestBaseOperator_RouteFuncdef(t *testing.T) {
+	fndefs := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, name := range []string{"hello-v1", "hello-v2"} {
+		fndefs.Add(&rfv1beta3.Funcdef{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name}}) // nolint:errcheck
+	}
+	triggers := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	r := &BaseOperator{
+		FuncdefLister: rflistersv1.NewFuncdefLister(fndefs),
+		TriggerLister: rflistersv1.NewTriggerLister(triggers),
+	}
+	trigger := &rfv1beta3.Trigger{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "hello"},
+		Spec: rfv1beta3.TriggerSpec{
+			FuncName: "hello-v1",
+			Traffic:  &rfv1beta3.TrafficSplit{FuncName: "hello-v2", Weight: 100},
+		},
+	}
+
+	route := func() string {
+		fndef, err := r.RouteFuncdef(trigger)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fndef.Name
+	}
+
+	// canary is not served at its endpoint
+	if got := route(); got != "hello-v1" {
+		t.Errorf("RouteFuncdef() = %q, want stable hello-v1", got)
+	}
+
+	triggers.Add(&rfv1beta3.Trigger{ // nolint:errcheck
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "hello-v2"},
+		Spec:       rfv1beta3.TriggerSpec{Type: gatewayTriggerType, FuncName: "hello-v2"},
+	})
+	if got := route(); got != "hello-v2" {
+		t.Errorf("RouteFuncdef() = %q, want canary hello-v2", got)
+	}
+}
@@ -0,0 +1,192 @@
+package commontrigger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"k8s.io/klog"
+
+	nats "github.com/nats-io/nats.go"
+	"github.com/nats-io/nuid"
+	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
+	"github.com/refunc/refunc/pkg/client"
+	"github.com/refunc/refunc/pkg/messages"
+	"github.com/refunc/refunc/pkg/sinks"
+	"github.com/refunc/refunc/pkg/utils"
+)
+
+const (
+	logsChunkSize  = 4<<(10*2) + 512<<10 // 4.5 MB
+	codeLaunchBias = 10 * time.Second
+)
+
+// invoke runs the function of trigger ns/name with args in msg,
+// the result is replied as messages.InvokeResponse.
+func (r *Operator) invoke(ns, name string, msg *nats.Msg) {
+	key := ns + "/" + name
+
+	var (
+		result []byte
+		err    error
+	)
+	defer func() {
+		if re := recover(); re != nil {
+			utils.LogTraceback(re, 5, klog.V(1))
+			err = fmt.Errorf("h: %v", re)
+		}
+		if msg.Reply == "" {
+			return
+		}
+		rsp := &messages.InvokeResponse{Error: messages.GetErrorMessage(err)}
+		if err == nil && len(result) > 0 {
+			if json.Valid(result) {
+				rsp.Payload = json.RawMessage(result)
+			} else {
+				rsp.Payload = messages.MustFromObject(string(result))
+			}
+		}
+		if err := msg.Respond(messages.MustFromObject(rsp)); err != nil {
+			klog.Errorf("(h) %s failed to reply, %v", key, err)
+		}
+	}()
+
+	trigger, err := r.TriggerLister.Triggers(ns).Get(name)
+	if err != nil {
+		return
+	}
+	if trigger.Spec.Type != Type || trigger.Spec.Common == nil {
+		err = fmt.Errorf("h: %s is not a common trigger", key)
+		return
+	}
+	fndef, err := r.ResolveFuncdef(trigger)
+	if err != nil {
+		return
+	}
+
+	var request messages.InvokeRequest
+	if len(bytes.TrimSpace(msg.Data)) > 0 {
+		if err = json.Unmarshal(msg.Data, &request); err != nil {
+			return
+		}
+	}
+	if request.Args, err = mergeArgs(trigger.Spec.Common.Args, request.Args); err != nil {
+		return
+	}
+	if request.RequestID == "" {
+		request.RequestID = nuid.Next()
+	}
+	result, err = r.run(trigger, fndef, &request)
+}
+
+// run executes request, and saves its logs and result as configured
+func (r *Operator) run(trigger *rfv1beta3.Trigger, fndef *rfv1beta3.Funcdef, request *messages.InvokeRequest) ([]byte, error) {
+	cfg := trigger.Spec.Common
+
+	var timeout = messages.DefaultJobTimeout
+	if fndef.Spec.Runtime != nil && fndef.Spec.Runtime.Timeout > 0 {
+		timeout = time.Second*time.Duration(fndef.Spec.Runtime.Timeout) + codeLaunchBias
+	}
+	ctx := r.ctx
+	ctx = client.WithLogger(ctx, klog.V(1))
+	ctx = client.WithTimeoutHint(ctx, timeout)
+	ctx = client.WithLoggingHint(ctx, cfg.SaveLog)
+
+	endpoint := fndef.Namespace + "/" + fndef.Name
+	taskr, err := client.NewTaskResolver(ctx, endpoint, request)
+	if err != nil {
+		return nil, err
+	}
+
+	run := &sinks.Run{
+		Namespace: trigger.Namespace,
+		Type:      Type,
+		Trigger:   trigger.Name,
+		FuncName:  trigger.Spec.FuncName,
+		ID:        taskr.ID(),
+		Time:      time.Now().UTC().Format(time.RFC3339Nano),
+	}
+	var sink sinks.Sink
+	if cfg.SaveLog || cfg.SaveResult {
+		if sink, err = sinks.CreateSink(r.ctx, cfg.Sink); err != nil {
+			klog.Errorf("(h) %s failed to create sink, outputs are dropped, %v", taskr.ID(), err)
+		}
+	}
+
+	logSeq := 0
+	writeLogs := func(lines []byte) {
+		if len(lines) == 0 || sink == nil {
+			return
+		}
+		key, err := sink.WriteLogs(run, logSeq, lines)
+		logSeq++
+		if err != nil {
+			klog.Errorf("(h) %s failed to write logs %q, %v", taskr.ID(), key, err)
+		}
+	}
+
+	logsteam := taskr.LogObserver()
+	var lines []byte
+	for done := false; !done; {
+		select {
+		case <-logsteam.Changes():
+			for logsteam.HasNext() {
+				lines = append(lines, []byte(logsteam.Next().(string))...)
+				lines = append(lines, messages.TokenCRLF...)
+			}
+			if len(lines) > logsChunkSize {
+				writeLogs(lines)
+				lines = lines[0:0]
+			}
+		case <-taskr.Done():
+			done = true
+		}
+	}
+	writeLogs(lines)
+
+	result, runErr := taskr.Result()
+	if cfg.SaveResult && sink != nil {
+		bts := result
+		if runErr != nil {
+			bts = messages.GetErrActionBytes(runErr)
+		}
+		if key, err := sink.WriteResult(run, bts); err != nil {
+			klog.Errorf("(h) %s failed to write results %q, %v", taskr.ID(), key, err)
+		}
+	}
+	return result, runErr
+}
+
+// mergeArgs returns args merged over defaults, args replaces defaults if any of them is not an object
+func mergeArgs(defaults, args json.RawMessage) (json.RawMessage, error) {
+	if isEmpty(args) {
+		if isEmpty(defaults) {
+			return json.RawMessage("{}"), nil
+		}
+		return defaults, nil
+	}
+	if isEmpty(defaults) {
+		return args, nil
+	}
+
+	var base, overrides map[string]interface{}
+	if json.Unmarshal(defaults, &base) != nil || json.Unmarshal(args, &overrides) != nil {
+		if !json.Valid(args) {
+			return nil, fmt.Errorf("h: args is not a valid json")
+		}
+		return args, nil
+	}
+	if base == nil {
+		base = make(map[string]interface{})
+	}
+	for k, v := range overrides {
+		base[k] = v
+	}
+	return json.Marshal(base)
+}
+
+func isEmpty(raw json.RawMessage) bool {
+	raw = bytes.TrimSpace(raw)
+	return len(raw) == 0 || bytes.Equal(raw, []byte("null"))
+}
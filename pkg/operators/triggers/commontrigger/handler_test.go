@@ -0,0 +1,43 @@
+package commontrigger
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func Test_mergeArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		defaults string
+		args     string
+		want     string
+		wantErr  bool
+	}{
+		{"empty", "", "", `{}`, false},
+		{"defaults only", `{"a":1}`, "null", `{"a":1}`, false},
+		{"args only", "", `{"b":2}`, `{"b":2}`, false},
+		{"merged", `{"a":1,"b":1}`, `{"b":2,"c":3}`, `{"a":1,"b":2,"c":3}`, false},
+		{"not object", `{"a":1}`, `[1,2]`, `[1,2]`, false},
+		{"invalid", `{"a":1}`, `{"b":`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mergeArgs(json.RawMessage(tt.defaults), json.RawMessage(tt.args))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("mergeArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			var gotV, wantV interface{}
+			if err := json.Unmarshal(got, &gotV); err != nil {
+				t.Fatal(err)
+			}
+			json.Unmarshal([]byte(tt.want), &wantV) // nolint:errcheck
+			if !reflect.DeepEqual(gotV, wantV) {
+				t.Errorf("mergeArgs() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package commontrigger
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog"
+
+	nats "github.com/nats-io/nats.go"
+	"github.com/refunc/refunc/pkg/client"
+	refunc "github.com/refunc/refunc/pkg/generated/clientset/versioned"
+	informers "github.com/refunc/refunc/pkg/generated/informers/externalversions"
+	"github.com/refunc/refunc/pkg/messages"
+	operators "github.com/refunc/refunc/pkg/operators"
+	"github.com/refunc/refunc/pkg/utils"
+)
+
+// Operator serves invocations of common triggers over NATS
+type Operator struct {
+	*operators.BaseOperator
+
+	// MaxInflight is the max number of concurrent invocations, defaults to DefaultMaxInflight
+	MaxInflight int
+
+	ctx context.Context
+
+	// limits concurrent invocations
+	inflight chan struct{}
+}
+
+// Type name for common trigger
+const Type = "commontrigger"
+
+// DefaultMaxInflight is the default max number of concurrent invocations of an operator
+const DefaultMaxInflight = 64
+
+var errTooManyInvocations = errors.New("h: too many concurrent invocations of common triggers")
+
+// NewOperator creates a new common trigger operator
+func NewOperator(
+	ctx context.Context,
+	cfg *rest.Config,
+	rclient refunc.Interface,
+	rfInformers informers.SharedInformerFactory,
+) (*Operator, error) {
+	base, err := operators.NewBaseOperator(cfg, rclient, rfInformers)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Operator{
+		BaseOperator: base,
+		ctx:          ctx,
+	}
+
+	return r, nil
+}
+
+// Run will not return until stopC is closed.
+func (r *Operator) Run(stopC <-chan struct{}) {
+	defer func() {
+		if re := recover(); re != nil {
+			utils.LogTraceback(re, 4, klog.V(1))
+		}
+	}()
+
+	natsConn := client.GetNatsConn(r.ctx)
+	if natsConn == nil {
+		klog.Error("(commontrigger) nats connection is required")
+		return
+	}
+
+	klog.Info("(commontrigger) starting common trigger operator")
+
+	maxInflight := r.MaxInflight
+	if maxInflight <= 0 {
+		maxInflight = DefaultMaxInflight
+	}
+	r.inflight = make(chan struct{}, maxInflight)
+
+	if !r.BaseOperator.WaitForCacheSync(stopC) {
+		klog.Error("(commontrigger) cannot fully sync resources")
+		return
+	}
+
+	ns := "*"
+	if r.Namespace != apiv1.NamespaceAll {
+		ns = r.Namespace
+	}
+	// replicas of operator share invocations in a queue group
+	sub, err := natsConn.QueueSubscribe("_refunc._triggers_."+ns+".*", "_refunc.commontriggers", r.handleMsg)
+	if err != nil {
+		klog.Errorf("(commontrigger) failed to subscribe, %v", err)
+		return
+	}
+	defer sub.Unsubscribe() // nolint:errcheck
+
+	<-stopC
+	klog.Info("(commontrigger) shuting down common trigger operator")
+}
+
+func (r *Operator) handleMsg(msg *nats.Msg) {
+	// _refunc._triggers_.<ns>.<name>
+	parts := strings.Split(msg.Subject, ".")
+	if len(parts) != 4 {
+		return
+	}
+	select {
+	case r.inflight <- struct{}{}:
+	default:
+		klog.Warningf("(commontrigger) reject %s/%s, %v", parts[2], parts[3], errTooManyInvocations)
+		if msg.Reply != "" {
+			msg.Respond(messages.MustFromObject(&messages.InvokeResponse{Error: messages.GetErrorMessage(errTooManyInvocations)})) // nolint:errcheck
+		}
+		return
+	}
+	go func() {
+		defer func() { <-r.inflight }()
+		r.invoke(parts[2], parts[3], msg)
+	}()
+}
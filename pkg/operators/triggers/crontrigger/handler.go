@@ -1,20 +1,17 @@
 package crontrigger
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
-	"path/filepath"
 	"sync"
 	"time"
 
 	"k8s.io/klog"
 
-	minio "github.com/minio/minio-go"
 	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
 	"github.com/refunc/refunc/pkg/client"
-	"github.com/refunc/refunc/pkg/env"
 	"github.com/refunc/refunc/pkg/messages"
+	"github.com/refunc/refunc/pkg/sinks"
 	"github.com/refunc/refunc/pkg/utils"
 	"github.com/robfig/cron/v3"
 )
@@ -24,29 +21,56 @@ type cronHandler struct {
 	ns    string
 	name  string
 
+	mu   sync.Mutex
 	next time.Time
+	// the latest run missed before the trigger is added
+	missed time.Time
+	// NextScheduleTime in status
+	reportedNext time.Time
+
+	// runs not finished, keyed by id of run
+	running sync.Map
+
+	// the last handled value of AnnotationRunNow
+	runNowToken string
 
 	operator *Operator
 }
 
 var tzdata sync.Map
 
+// loadLocation returns the location of name, the result is cached
+func loadLocation(name string) (*time.Location, error) {
+	if tz, ok := tzdata.Load(name); ok {
+		return tz.(*time.Location), nil
+	}
+	tz, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	tzdata.Store(name, tz)
+	return tz, nil
+}
+
 func (t *cronHandler) Next() (next time.Time, err error) {
 	trigger, err := t.operator.TriggerLister.Triggers(t.ns).Get(t.name)
 	if err != nil {
 		return
 	}
 
-	tz, ok := tzdata.Load(trigger.Spec.Cron.Location)
-	if !ok {
-		tz, err = time.LoadLocation(trigger.Spec.Cron.Location)
-		if err != nil {
-			return
-		}
-		tzdata.Store(trigger.Spec.Cron.Location, tz)
+	tz, err := loadLocation(trigger.Spec.Cron.Location)
+	if err != nil {
+		return
 	}
-	now := time.Now().In(tz.(*time.Location))
+	now := time.Now().In(tz)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
 
+	if !t.missed.IsZero() {
+		// catch up first
+		return t.missed, nil
+	}
 	if !t.next.IsZero() && t.next.After(now) {
 		// trigger's cron is not changed, and trigger is not executed related to `now`, so return last evaluated time.
 		// this ensure cron expression with @every x, @hourly works well
@@ -60,16 +84,134 @@ func (t *cronHandler) Next() (next time.Time, err error) {
 	if err != nil {
 		return
 	}
+	firstTime := t.next.IsZero()
 	t.next = sched.Next(now)
+
+	if firstTime && trigger.Status.LastScheduleTime != nil {
+		from := trigger.Status.LastScheduleTime.Time.In(now.Location())
+		if deadline, ok := startingDeadline(trigger); ok && now.Add(-deadline).After(from) {
+			// runs before the deadline will never be started
+			from = now.Add(-deadline)
+		}
+		var tooMany bool
+		if t.missed, tooMany = latestSchedule(sched, from, now); tooMany {
+			klog.Warningf("(h) %s missed more than %d runs, skips catching up, set startingDeadlineSeconds to limit missed runs", t.trKey, maxMissedSchedules)
+		}
+		if !t.missed.IsZero() {
+			klog.Infof("(h) %s catching up the run missed at %v", t.trKey, t.missed)
+			return t.missed, nil
+		}
+	}
 	return t.next, nil
 }
 
-const (
-	s3Prefix      = "_system/crontriggers"
-	logsChunkSize = 4<<(10*2) + 512<<10 // 4.5 MB
-)
+// fired marks the run of tm is handled
+func (t *cronHandler) fired(tm time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.missed.Equal(tm) {
+		t.missed = time.Time{}
+	}
+}
+
+// maxMissedSchedules limits the runs walked through when catching up, as CronJob does
+const maxMissedSchedules = 100
 
+// latestSchedule returns the latest time scheduled in (from, now], zero if none
+// or more than maxMissedSchedules runs are missed
+func latestSchedule(sched cron.Schedule, from, now time.Time) (latest time.Time, tooMany bool) {
+	missed := 0
+	for next := sched.Next(from); !next.IsZero() && !next.After(now); next = sched.Next(next) {
+		if missed++; missed > maxMissedSchedules {
+			return time.Time{}, true
+		}
+		latest = next
+	}
+	return
+}
+
+// startingDeadline returns the StartingDeadlineSeconds of trigger if set
+func startingDeadline(trigger *rfv1beta3.Trigger) (time.Duration, bool) {
+	if trigger.Spec.Cron == nil || trigger.Spec.Cron.StartingDeadlineSeconds == nil {
+		return 0, false
+	}
+	return time.Duration(*trigger.Spec.Cron.StartingDeadlineSeconds) * time.Second, true
+}
+
+// cronRun is a run of the trigger, it's running until its last attempt finishes,
+// including backoffs between attempts.
+type cronRun struct {
+	id string
+
+	mu sync.Mutex
+	// task of the current attempt
+	taskr     client.TaskResolver
+	cancelled bool
+	cancelC   chan struct{}
+}
+
+func newCronRun(id string, taskr client.TaskResolver) *cronRun {
+	return &cronRun{id: id, taskr: taskr, cancelC: make(chan struct{})}
+}
+
+// setTask sets the task of next attempt, returns false if the run is cancelled
+func (run *cronRun) setTask(taskr client.TaskResolver) bool {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	if run.cancelled {
+		return false
+	}
+	run.taskr = taskr
+	return true
+}
+
+// cancel stops the run, its current attempt is cancelled and no more attempts are made
+func (run *cronRun) cancel() {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	if run.cancelled {
+		return
+	}
+	run.cancelled = true
+	close(run.cancelC)
+	if run.taskr != nil {
+		run.taskr.Cancel()
+	}
+}
+
+func (run *cronRun) isCancelled() bool {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	return run.cancelled
+}
+
+// runningRuns returns runs of the trigger that are not finished
+func (t *cronHandler) runningRuns() (runs []*cronRun) {
+	t.running.Range(func(_, val interface{}) bool {
+		runs = append(runs, val.(*cronRun))
+		return true
+	})
+	return
+}
+
+const logsChunkSize = 4<<(10*2) + 512<<10 // 4.5 MB
+
+// Run starts the run scheduled at tm
 func (t *cronHandler) Run(tm time.Time) {
+	t.run(tm, true)
+}
+
+// RunNow starts an on-demand run, which is not counted as a scheduled one
+func (t *cronHandler) RunNow() {
+	t.run(time.Now(), false)
+}
+
+// suspended returns true if scheduling of trigger is suspended
+func suspended(trigger *rfv1beta3.Trigger) bool {
+	return trigger.Spec.Cron != nil && trigger.Spec.Cron.Suspend
+}
+
+func (t *cronHandler) run(tm time.Time, scheduled bool) {
 	trigger, err := t.operator.TriggerLister.Triggers(t.ns).Get(t.name)
 	if err != nil {
 		klog.Errorf("(h) %s failed to get trigger, %v", t.trKey, err)
@@ -81,8 +223,34 @@ func (t *cronHandler) Run(tm time.Time) {
 		klog.Errorf("(h) %s failed to get fundef, %v", t.trKey, err)
 		return
 	}
+
+	switch trigger.Spec.Cron.ConcurrencyPolicy {
+	case rfv1beta3.CronForbidConcurrent:
+		if running := t.runningRuns(); len(running) > 0 {
+			klog.Warningf("(h) %s skips run of %v, %s is still running", t.trKey, tm, running[0].id)
+			if scheduled {
+				t.markScheduled(tm)
+			}
+			return
+		}
+	case rfv1beta3.CronReplaceConcurrent:
+		for _, running := range t.runningRuns() {
+			klog.Warningf("(h) %s replacing running task %s", t.trKey, running.id)
+			// replaced runs are never retried
+			t.running.Delete(running.id)
+			running.cancel()
+		}
+	}
+
 	ts := tm.Truncate(time.Second).Format(time.RFC3339)
-	taskr, created, err := t.ensureTask(fndef, trigger, ts)
+	// args are rendered once, all attempts of the run are sent with the same args
+	args, redactedArgs, err := t.operator.renderArgs(trigger, ts)
+	if err != nil {
+		klog.Errorf("(h) failed to start task %s, %v", t.trKey, err)
+		return
+	}
+	runKey := t.runKey(ts, scheduled)
+	taskr, created, err := t.ensureTask(fndef, trigger, runKey, 1, args)
 	if err != nil {
 		klog.Errorf("(h) failed to start task %s, %v", t.trKey, err)
 		return
@@ -91,100 +259,157 @@ func (t *cronHandler) Run(tm time.Time) {
 		klog.Warningf("(h) %s is already started", taskr.ID())
 		return
 	}
+	runID := taskr.ID()
+	cr := newCronRun(runID, taskr)
+	t.running.Store(runID, cr)
+	startTime := time.Now()
+	t.markStarted(tm, scheduled, runID, startTime)
 
 	// start and polling
 	go func() {
 		klog.Warningf("(h) %s started", taskr.ID())
+		var (
+			runErr    error
+			logKeys   []string
+			resultKey string
+		)
 		defer func() {
 			re := recover()
-			t.operator.liveTasks.Delete(taskr.ID())
 			if re != nil {
 				utils.LogTraceback(re, 5, klog.V(1))
-				err = fmt.Errorf("h: %v", re)
+				runErr = fmt.Errorf("h: %v", re)
 			}
+			t.running.Delete(runID)
+			t.markFinished(runID, startTime, runErr, logKeys, resultKey)
 		}()
 
-		logsteam := taskr.LogObserver()
-		logNameSuffix := 0
-		logsName := func() string {
-			suffix := ""
-			if logNameSuffix > 0 {
-				suffix = fmt.Sprintf(".%d", logNameSuffix)
-			}
-			logNameSuffix++
-			return env.KeyWithinScope(filepath.Join(
-				t.ns,
-				s3Prefix,
-				t.name,
-				"logs",
-				fmt.Sprintf("%s%s.log", ts, suffix),
-			))
+		run := &sinks.Run{
+			Namespace: t.ns,
+			Type:      Type,
+			Trigger:   t.name,
+			FuncName:  trigger.Spec.FuncName,
+			ID:        runID,
+			Time:      ts,
 		}
+		sink, err := sinks.CreateSink(t.operator.ctx, trigger.Spec.Cron.Sink)
+		if err != nil {
+			klog.Errorf("(h) %s failed to create sink, outputs are dropped, %v", runID, err)
+		}
+		logSeq := 0
 		writeLogs := func(lines []byte) {
-			if len(lines) == 0 {
+			if len(lines) == 0 || sink == nil {
 				return
 			}
-			key := logsName()
-			_, err := env.GlobalMinioClient().PutObject(
-				env.GlobalBucket, key,
-				bytes.NewReader(lines), int64(len(lines)),
-				minio.PutObjectOptions{ContentType: "text/plain; charset=UTF-8"},
-			)
+			key, err := sink.WriteLogs(run, logSeq, lines)
+			logSeq++
 			if err != nil {
-				klog.Errorf("(h) %s failed to write logs %q, %v", taskr.ID(), key, err)
+				klog.Errorf("(h) %s failed to write logs %q, %v", runID, key, err)
+				return
 			}
+			logKeys = append(logKeys, key)
 		}
 
-		var lines []byte
-		defer func() { writeLogs(lines) }()
-		for {
+		policy := trigger.Spec.Cron.Retry
+		var (
+			bts      []byte
+			replaced bool
+		)
+		attempt := 1
+		for ; ; attempt++ {
+			bts, runErr = t.watchTask(taskr, writeLogs)
+			if cr.isCancelled() {
+				klog.Warningf("(h) %s is replaced", taskr.ID())
+				replaced = true
+				break
+			}
+			if runErr == nil || !shouldRetry(policy, attempt, runErr) {
+				break
+			}
+			backoff := retryBackoff(policy, attempt)
+			klog.Warningf("(h) %s attempt %d failed, retry in %v, %v", runID, attempt, backoff, runErr)
 			select {
-			case <-logsteam.Changes():
-				for logsteam.HasNext() {
-					lines = append(lines, []byte(logsteam.Next().(string))...)
-					lines = append(lines, messages.TokenCRLF...)
-				}
-				if len(lines) > logsChunkSize {
-					writeLogs(lines)
-					lines = lines[0:0]
-				}
-
-			case <-taskr.Done():
-				bts, err := taskr.Result()
-				if err != nil {
-					klog.Errorf("(h) %s failed, %v", taskr.ID(), err)
-					bts = messages.GetErrActionBytes(err)
-				}
-				if !trigger.Spec.Cron.SaveResult {
-					return
-				}
-				// write result
-				key := env.KeyWithinScope(filepath.Join(
-					t.ns,
-					s3Prefix,
-					t.name,
-					"results",
-					fmt.Sprintf("%s.json", ts),
-				))
-				_, err = env.GlobalMinioClient().PutObject(
-					env.GlobalBucket, key,
-					bytes.NewReader(bts), int64(len(bts)),
-					minio.PutObjectOptions{ContentType: "text/plain; charset=UTF-8"},
-				)
-				if err != nil {
-					klog.Errorf("(h) %s failed to write results %q, %v", taskr.ID(), key, err)
-				}
-				return
+			case <-t.operator.ctx.Done():
+				runErr = t.operator.ctx.Err()
+			case <-cr.cancelC:
+				klog.Warningf("(h) %s is replaced during backoff", runID)
+				replaced = true
+			case <-time.After(backoff):
+				taskr, _, runErr = t.ensureTask(fndef, trigger, runKey, attempt+1, args)
+			}
+			if replaced || runErr != nil {
+				break
+			}
+			if !cr.setTask(taskr) {
+				// replaced before the attempt is tracked
+				taskr.Cancel()
+				replaced = true
+				break
 			}
 		}
+
+		if runErr != nil {
+			klog.Errorf("(h) %s failed, %v", runID, runErr)
+			bts = messages.GetErrActionBytes(runErr)
+			if policy != nil && !replaced {
+				t.deadLetter(trigger, run, sink, attempt, redactedArgs, runErr)
+			}
+		}
+		if !trigger.Spec.Cron.SaveResult || sink == nil {
+			return
+		}
+		// write result
+		key, err := sink.WriteResult(run, bts)
+		if err != nil {
+			klog.Errorf("(h) %s failed to write results %q, %v", runID, key, err)
+			return
+		}
+		resultKey = key
 	}()
 }
 
+// watchTask saves logs of taskr until it's done, returns its result
+func (t *cronHandler) watchTask(taskr client.TaskResolver, writeLogs func([]byte)) ([]byte, error) {
+	defer t.operator.liveTasks.Delete(taskr.ID())
+
+	logsteam := taskr.LogObserver()
+	var lines []byte
+	defer func() { writeLogs(lines) }()
+	for {
+		select {
+		case <-logsteam.Changes():
+			for logsteam.HasNext() {
+				lines = append(lines, []byte(logsteam.Next().(string))...)
+				lines = append(lines, messages.TokenCRLF...)
+			}
+			if len(lines) > logsChunkSize {
+				writeLogs(lines)
+				lines = lines[0:0]
+			}
+
+		case <-taskr.Done():
+			return taskr.Result()
+		}
+	}
+}
+
 const codeLaunchBias = 10 * time.Second
 
-// ensureTask gets or creates a client.TaskResolver
-func (t *cronHandler) ensureTask(fndef *rfv1beta3.Funcdef, trigger *rfv1beta3.Trigger, ts string) (client.TaskResolver, bool, error) {
-	id := utils.GenID([]byte(t.trKey + "@" + ts))
+// runKey returns the key of the run at ts, on-demand runs are keyed apart from
+// the scheduled one of the same second, otherwise they are dropped as started
+func (t *cronHandler) runKey(ts string, scheduled bool) string {
+	runKey := t.trKey + "@" + ts
+	if !scheduled {
+		runKey += "#now"
+	}
+	return runKey
+}
+
+// ensureTask gets or creates a client.TaskResolver for an attempt of the run
+func (t *cronHandler) ensureTask(fndef *rfv1beta3.Funcdef, trigger *rfv1beta3.Trigger, runKey string, attempt int, args json.RawMessage) (client.TaskResolver, bool, error) {
+	if attempt > 1 {
+		runKey += fmt.Sprintf("#%d", attempt)
+	}
+	id := utils.GenID([]byte(runKey))
 	return t.operator.liveTasks.GetOrCreateTask(id, func() (_ client.TaskResolver, err error) {
 		defer func() {
 			re := recover()
@@ -197,23 +422,9 @@ func (t *cronHandler) ensureTask(fndef *rfv1beta3.Funcdef, trigger *rfv1beta3.Tr
 			}
 		}()
 
-		var args map[string]interface{}
-		if trigger.Spec.Cron.Args != nil {
-			// render request data
-			err = json.Unmarshal(trigger.Spec.Cron.Args, &args)
-			if err != nil {
-				return
-			}
-		}
-		if len(args) == 0 {
-			args = make(map[string]interface{})
-		}
-		args["$time"] = ts
-		args["$triggerName"] = trigger.Name
-
 		endpoint := trigger.Namespace + "/" + trigger.Spec.FuncName
 		request := &messages.InvokeRequest{
-			Args:      messages.MustFromObject(args),
+			Args:      args,
 			RequestID: id,
 		}
 		var timeout = messages.DefaultJobTimeout
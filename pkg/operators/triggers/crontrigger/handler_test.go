@@ -0,0 +1,125 @@
+package crontrigger
+
+import (
+	"testing"
+	"time"
+
+	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
+	"github.com/refunc/refunc/pkg/client"
+	"github.com/refunc/refunc/pkg/messages"
+	"github.com/robfig/cron/v3"
+)
+
+func Test_latestSchedule(t *testing.T) {
+	sched, err := cron.ParseStandard("*/10 * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(hhmm string) time.Time {
+		tm, _ := time.Parse("15:04", hhmm)
+		return tm
+	}
+	tests := []struct {
+		from, now string
+		want      time.Time
+		tooMany   bool
+	}{
+		{"10:00", "10:05", time.Time{}, false},
+		{"10:00", "10:10", at("10:10"), false},
+		{"10:00", "10:35", at("10:30"), false},
+		{"10:05", "11:59", at("11:50"), false},
+		{"00:00", "23:59", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from+"-"+tt.now, func(t *testing.T) {
+			got, tooMany := latestSchedule(sched, at(tt.from), at(tt.now))
+			if !got.Equal(tt.want) || tooMany != tt.tooMany {
+				t.Errorf("latestSchedule() = %v, %v, want %v, %v", got, tooMany, tt.want, tt.tooMany)
+			}
+		})
+	}
+}
+
+func Test_retryBackoff(t *testing.T) {
+	policy := &rfv1beta3.CronTriggerRetry{MaxAttempts: 5, InitialBackoffSeconds: 2, MaxBackoffSeconds: 10}
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 2 * time.Second},
+		{2, 4 * time.Second},
+		{3, 8 * time.Second},
+		{4, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := retryBackoff(policy, tt.attempt); got != tt.want {
+			t.Errorf("retryBackoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func Test_shouldRetry(t *testing.T) {
+	errTimeout := messages.ErrorMessage{Type: "Timeout", Message: "timeout"}
+	errFatal := messages.ErrorMessage{Type: "Fatal", Message: "fatal"}
+	tests := []struct {
+		name    string
+		policy  *rfv1beta3.CronTriggerRetry
+		attempt int
+		err     error
+		want    bool
+	}{
+		{"no policy", nil, 1, errTimeout, false},
+		{"any error", &rfv1beta3.CronTriggerRetry{MaxAttempts: 3}, 2, errFatal, true},
+		{"exhausted", &rfv1beta3.CronTriggerRetry{MaxAttempts: 3}, 3, errTimeout, false},
+		{"retryable", &rfv1beta3.CronTriggerRetry{MaxAttempts: 3, RetryOn: []string{"Timeout"}}, 1, errTimeout, true},
+		{"not retryable", &rfv1beta3.CronTriggerRetry{MaxAttempts: 3, RetryOn: []string{"Timeout"}}, 1, errFatal, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shouldRetry(tt.policy, tt.attempt, tt.err); got != tt.want {
+				t.Errorf("shouldRetry() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type cancelCounter struct {
+	client.TaskResolver
+	cancelled int
+}
+
+func (c *cancelCounter) Cancel() { c.cancelled++ }
+
+func Test_cronRun_cancel(t *testing.T) {
+	first, second := new(cancelCounter), new(cancelCounter)
+	run := newCronRun("run", first)
+
+	if !run.setTask(second) {
+		t.Fatal("setTask() = false before cancelled")
+	}
+	run.cancel()
+	run.cancel()
+	if first.cancelled != 0 || second.cancelled != 1 {
+		t.Errorf("cancelled %d, %d times, want only the current attempt once", first.cancelled, second.cancelled)
+	}
+	select {
+	case <-run.cancelC:
+	default:
+		t.Error("cancelC is not closed, backoff will not be interrupted")
+	}
+	if run.setTask(first) || !run.isCancelled() {
+		t.Error("setTask() = true after cancelled")
+	}
+}
+
+func Test_cronHandler_runKey(t *testing.T) {
+	h := &cronHandler{trKey: "ns/cron"}
+	ts := "2024-01-01T00:00:00Z"
+	scheduled, onDemand := h.runKey(ts, true), h.runKey(ts, false)
+	if scheduled != "ns/cron@"+ts {
+		t.Errorf("runKey(scheduled) = %q", scheduled)
+	}
+	if onDemand == scheduled {
+		t.Errorf("runKey(on-demand) = %q, collides with the scheduled run", onDemand)
+	}
+}
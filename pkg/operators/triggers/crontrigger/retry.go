@@ -0,0 +1,97 @@
+package crontrigger
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"k8s.io/klog"
+
+	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
+	"github.com/refunc/refunc/pkg/client"
+	"github.com/refunc/refunc/pkg/messages"
+	"github.com/refunc/refunc/pkg/sinks"
+)
+
+// default backoffs of retries
+const (
+	defaultInitialBackoff = time.Second
+	defaultMaxBackoff     = time.Minute
+)
+
+// DeadLetter is the document saved for a run that failed after all attempts,
+// it's also the args of FailureHandler.
+type DeadLetter struct {
+	Namespace string                 `json:"namespace"`
+	Trigger   string                 `json:"trigger"`
+	FuncName  string                 `json:"funcName"`
+	Time      string                 `json:"time"`
+	Attempts  int                    `json:"attempts"`
+	Args      json.RawMessage        `json:"args"`
+	Error     *messages.ErrorMessage `json:"error"`
+}
+
+// shouldRetry returns true if a run that failed at attempt with err could be retried
+func shouldRetry(policy *rfv1beta3.CronTriggerRetry, attempt int, err error) bool {
+	if policy == nil || attempt >= policy.MaxAttempts {
+		return false
+	}
+	if len(policy.RetryOn) == 0 {
+		return true
+	}
+	errType := messages.GetErrorMessage(err).Type
+	for _, typ := range policy.RetryOn {
+		if typ == errType {
+			return true
+		}
+	}
+	return false
+}
+
+// retryBackoff returns the delay after a failed attempt, it doubles after each attempt
+func retryBackoff(policy *rfv1beta3.CronTriggerRetry, attempt int) time.Duration {
+	backoff, maxBackoff := defaultInitialBackoff, defaultMaxBackoff
+	if policy.InitialBackoffSeconds > 0 {
+		backoff = time.Duration(policy.InitialBackoffSeconds) * time.Second
+	}
+	if policy.MaxBackoffSeconds > 0 {
+		maxBackoff = time.Duration(policy.MaxBackoffSeconds) * time.Second
+	}
+	for i := 1; i < attempt && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	return backoff
+}
+
+// deadLetter saves the run to sink, and invokes the FailureHandler if set,
+// args are the ones sent to the function with secrets redacted.
+func (t *cronHandler) deadLetter(trigger *rfv1beta3.Trigger, run *sinks.Run, sink sinks.Sink, attempts int, args json.RawMessage, runErr error) {
+	letter := &DeadLetter{
+		Namespace: trigger.Namespace,
+		Trigger:   trigger.Name,
+		FuncName:  trigger.Spec.FuncName,
+		Time:      run.Time,
+		Attempts:  attempts,
+		Args:      args,
+		Error:     messages.GetErrorMessage(runErr),
+	}
+
+	if sink != nil {
+		if key, err := sink.WriteDeadLetter(run, messages.MustFromObject(letter)); err != nil {
+			klog.Errorf("(h) %s failed to write dead letter %q, %v", t.trKey, key, err)
+		}
+	}
+
+	if handler := trigger.Spec.Cron.Retry.FailureHandler; handler != "" {
+		go func() {
+			ctx, cancel := context.WithTimeout(t.operator.ctx, messages.DefaultJobTimeout)
+			defer cancel()
+			if _, err := client.Invoke(ctx, trigger.Namespace+"/"+handler, letter); err != nil {
+				klog.Errorf("(h) %s failed to invoke failure handler %s, %v", t.trKey, handler, err)
+			}
+		}()
+	}
+}
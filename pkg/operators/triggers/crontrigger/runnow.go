@@ -0,0 +1,60 @@
+package crontrigger
+
+import (
+	"context"
+	"encoding/json"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+
+	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
+)
+
+// handleRunNow starts an on-demand run if trigger is annotated by AnnotationRunNow,
+// the annotation is removed once the run is started.
+//
+//	kubectl annotate triggers <name> refunc.io/run-now=$(date +%s)
+func (r *Operator) handleRunNow(trigger *rfv1beta3.Trigger) {
+	token := trigger.Annotations[rfv1beta3.AnnotationRunNow]
+	if token == "" {
+		return
+	}
+	val, ok := r.triggers.Load(k8sKey(trigger))
+	if !ok {
+		return
+	}
+	h := val.(*cronHandler)
+
+	h.mu.Lock()
+	if h.runNowToken == token {
+		// already handled, waiting for the annotation to be removed
+		h.mu.Unlock()
+		return
+	}
+	h.runNowToken = token
+	h.mu.Unlock()
+
+	go func() {
+		klog.Infof("(crontrigger) %s run now, token %q", h.trKey, token)
+		h.RunNow()
+		if err := r.removeRunNow(trigger.Namespace, trigger.Name); err != nil {
+			klog.Errorf("(crontrigger) %s failed to remove annotation %s, %v", h.trKey, rfv1beta3.AnnotationRunNow, err)
+		}
+	}()
+}
+
+func (r *Operator) removeRunNow(ns, name string) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				rfv1beta3.AnnotationRunNow: nil,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = r.RefuncClient.RefuncV1beta3().Triggers(ns).Patch(context.TODO(), name, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
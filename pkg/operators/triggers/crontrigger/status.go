@@ -0,0 +1,108 @@
+package crontrigger
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog"
+
+	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
+)
+
+// updateStatus applies update to the latest status of a trigger,
+// update returns false if there is nothing to change.
+func (r *Operator) updateStatus(ns, name string, update func(*rfv1beta3.TriggerStatus) bool) {
+	triggers := r.RefuncClient.RefuncV1beta3().Triggers(ns)
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cur, err := triggers.Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if !update(&cur.Status) {
+			return nil
+		}
+		_, err = triggers.UpdateStatus(context.TODO(), cur, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		klog.Errorf("(crontrigger) %s/%s failed to update status, %v", ns, name, err)
+	}
+}
+
+// setScheduleTime sets LastScheduleTime if tm is later
+func setScheduleTime(status *rfv1beta3.TriggerStatus, tm time.Time) bool {
+	if last := status.LastScheduleTime; last != nil && !last.Time.Before(tm) {
+		return false
+	}
+	status.LastScheduleTime = &metav1.Time{Time: tm}
+	return true
+}
+
+// markScheduled records the run of tm is handled without starting a task,
+// so that it will not be caught up after restarts.
+func (t *cronHandler) markScheduled(tm time.Time) {
+	t.operator.updateStatus(t.ns, t.name, func(status *rfv1beta3.TriggerStatus) bool {
+		return setScheduleTime(status, tm)
+	})
+}
+
+func (t *cronHandler) markStarted(tm time.Time, scheduled bool, id string, start time.Time) {
+	t.operator.updateStatus(t.ns, t.name, func(status *rfv1beta3.TriggerStatus) bool {
+		if scheduled {
+			setScheduleTime(status, tm)
+		}
+		status.AddRun(rfv1beta3.TriggerRun{
+			ID:        id,
+			StartTime: metav1.Time{Time: start},
+			Phase:     rfv1beta3.TriggerRunRunning,
+		})
+		return true
+	})
+}
+
+func (t *cronHandler) markFinished(id string, start time.Time, runErr error, logKeys []string, resultKey string) {
+	now := time.Now()
+	t.operator.updateStatus(t.ns, t.name, func(status *rfv1beta3.TriggerStatus) bool {
+		run := status.GetRun(id)
+		if run == nil {
+			// dropped from history
+			return false
+		}
+		run.Duration = &metav1.Duration{Duration: now.Sub(start).Truncate(time.Millisecond)}
+		run.LogKeys, run.ResultKey = logKeys, resultKey
+		if runErr != nil {
+			run.Phase, run.Message = rfv1beta3.TriggerRunFailed, runErr.Error()
+			return true
+		}
+		run.Phase = rfv1beta3.TriggerRunSucceeded
+		status.LastSuccessfulTime = &metav1.Time{Time: now}
+		return true
+	})
+}
+
+// reportNext updates NextScheduleTime once next is changed,
+// a zero next clears it.
+func (t *cronHandler) reportNext(next time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.reportedNext.Equal(next) {
+		return
+	}
+	t.reportedNext = next
+	go t.operator.updateStatus(t.ns, t.name, func(status *rfv1beta3.TriggerStatus) bool {
+		if next.IsZero() {
+			if status.NextScheduleTime == nil {
+				return false
+			}
+			status.NextScheduleTime = nil
+			return true
+		}
+		if status.NextScheduleTime != nil && status.NextScheduleTime.Time.Equal(next.Truncate(time.Second)) {
+			return false
+		}
+		status.NextScheduleTime = &metav1.Time{Time: next}
+		return true
+	})
+}
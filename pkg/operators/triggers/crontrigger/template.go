@@ -0,0 +1,200 @@
+package crontrigger
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
+	"github.com/refunc/refunc/pkg/messages"
+)
+
+// argsData is the data of templates in CronTrigger.Args
+//
+//	{
+//	  "date": "{{ .Time | format \"2006-01-02\" }}",
+//	  "from": "{{ .Time | yesterday | format \"RFC3339\" }}",
+//	  "since": "{{ .LastSuccessfulTime | format \"RFC3339\" }}",
+//	  "bucket": "{{ configMap \"etl\" \"bucket\" }}"
+//	}
+type argsData struct {
+	// Time is the scheduled time in trigger's location
+	Time time.Time
+	// LastSuccessfulTime is the time the last run succeeded, zero if never
+	LastSuccessfulTime time.Time
+
+	Namespace   string
+	TriggerName string
+}
+
+// named layouts for the format helper
+var timeLayouts = map[string]string{
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"RFC1123":     time.RFC1123,
+	"date":        "2006-01-02",
+	"datetime":    "2006-01-02 15:04:05",
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+var timeFuncs = template.FuncMap{
+	"format": func(layout string, t time.Time) string {
+		if l, ok := timeLayouts[layout]; ok {
+			layout = l
+		}
+		return t.Format(layout)
+	},
+	"unix": func(t time.Time) int64 {
+		return t.Unix()
+	},
+	"in": func(name string, t time.Time) (time.Time, error) {
+		tz, err := loadLocation(name)
+		if err != nil {
+			return t, err
+		}
+		return t.In(tz), nil
+	},
+	"add": func(duration string, t time.Time) (time.Time, error) {
+		d, err := time.ParseDuration(duration)
+		return t.Add(d), err
+	},
+	"addDate": func(years, months, days int, t time.Time) time.Time {
+		return t.AddDate(years, months, days)
+	},
+	"addDays": func(days int, t time.Time) time.Time {
+		return t.AddDate(0, 0, days)
+	},
+	"truncate": func(duration string, t time.Time) (time.Time, error) {
+		d, err := time.ParseDuration(duration)
+		return t.Truncate(d), err
+	},
+	"startOfDay": startOfDay,
+	"yesterday": func(t time.Time) time.Time {
+		return startOfDay(t).AddDate(0, 0, -1)
+	},
+	"tomorrow": func(t time.Time) time.Time {
+		return startOfDay(t).AddDate(0, 0, 1)
+	},
+}
+
+// redactedValue replaces string values rendered from secrets in redacted args
+const redactedValue = "[REDACTED]"
+
+// renderArgs returns args of the run at ts, string values in Args are rendered as go templates,
+// and a copy of args whose values rendered from secrets are redacted, which is safe to be saved.
+func (r *Operator) renderArgs(trigger *rfv1beta3.Trigger, ts string) (args, redacted json.RawMessage, err error) {
+	var values map[string]interface{}
+	if trigger.Spec.Cron.Args != nil {
+		// render request data
+		if err := json.Unmarshal(trigger.Spec.Cron.Args, &values); err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(values) == 0 {
+		values = make(map[string]interface{})
+	}
+
+	tm, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		return nil, nil, err
+	}
+	tz, err := loadLocation(trigger.Spec.Cron.Location)
+	if err != nil {
+		return nil, nil, err
+	}
+	data := &argsData{
+		Time:        tm.In(tz),
+		Namespace:   trigger.Namespace,
+		TriggerName: trigger.Name,
+	}
+	if last := trigger.Status.LastSuccessfulTime; last != nil {
+		data.LastSuccessfulTime = last.Time.In(tz)
+	}
+
+	// set if the template being rendered reads a secret
+	var secretRead bool
+	funcs := template.FuncMap{
+		"configMap": func(name, key string) (string, error) {
+			cm, err := r.configMapLister.ConfigMaps(trigger.Namespace).Get(name)
+			if err != nil {
+				return "", err
+			}
+			if v, ok := cm.Data[key]; ok {
+				return v, nil
+			}
+			return "", fmt.Errorf("key %q not found in configmap %s", key, name)
+		},
+		"secret": func(name, key string) (string, error) {
+			secretRead = true
+			secret, err := r.secretLister.Secrets(trigger.Namespace).Get(name)
+			if err != nil {
+				return "", err
+			}
+			if v, ok := secret.Data[key]; ok {
+				return string(v), nil
+			}
+			return "", fmt.Errorf("key %q not found in secret %s", key, name)
+		},
+	}
+
+	// render returns the rendered value of v and its redacted copy
+	var render func(v interface{}) (interface{}, interface{}, error)
+	render = func(v interface{}) (interface{}, interface{}, error) {
+		switch val := v.(type) {
+		case string:
+			if !strings.Contains(val, "{{") {
+				return val, val, nil
+			}
+			tmpl, err := template.New("args").Funcs(timeFuncs).Funcs(funcs).Option("missingkey=error").Parse(val)
+			if err != nil {
+				return nil, nil, err
+			}
+			var sb strings.Builder
+			secretRead = false
+			if err := tmpl.Execute(&sb, data); err != nil {
+				return nil, nil, err
+			}
+			if secretRead {
+				return sb.String(), redactedValue, nil
+			}
+			return sb.String(), sb.String(), nil
+		case map[string]interface{}:
+			redacted := make(map[string]interface{}, len(val))
+			for k, item := range val {
+				rendered, redactedItem, err := render(item)
+				if err != nil {
+					return nil, nil, fmt.Errorf("%s: %v", k, err)
+				}
+				val[k], redacted[k] = rendered, redactedItem
+			}
+			return val, redacted, nil
+		case []interface{}:
+			redacted := make([]interface{}, len(val))
+			for i, item := range val {
+				rendered, redactedItem, err := render(item)
+				if err != nil {
+					return nil, nil, fmt.Errorf("[%d]: %v", i, err)
+				}
+				val[i], redacted[i] = rendered, redactedItem
+			}
+			return val, redacted, nil
+		}
+		return v, v, nil
+	}
+	_, redactedValues, err := render(values)
+	if err != nil {
+		return nil, nil, fmt.Errorf("crontrigger: failed to render args, %v", err)
+	}
+
+	for _, m := range []map[string]interface{}{values, redactedValues.(map[string]interface{})} {
+		m["$time"] = ts
+		m["$triggerName"] = trigger.Name
+	}
+	return messages.MustFromObject(values), messages.MustFromObject(redactedValues), nil
+}
@@ -0,0 +1,106 @@
+package crontrigger
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
+)
+
+func TestOperator_renderArgs(t *testing.T) {
+	cms := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	cms.Add(&corev1.ConfigMap{ // nolint:errcheck
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "etl"},
+		Data:       map[string]string{"bucket": "warehouse"},
+	})
+	secrets := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	secrets.Add(&corev1.Secret{ // nolint:errcheck
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "etl"},
+		Data:       map[string][]byte{"token": []byte("s3cr3t")},
+	})
+	r := &Operator{
+		configMapLister: corelisters.NewConfigMapLister(cms),
+		secretLister:    corelisters.NewSecretLister(secrets),
+	}
+
+	last := metav1.NewTime(time.Date(2026, 3, 1, 16, 0, 0, 0, time.UTC))
+	newTrigger := func(args string) *rfv1beta3.Trigger {
+		return &rfv1beta3.Trigger{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "nightly"},
+			Spec: rfv1beta3.TriggerSpec{
+				TriggerConfig: rfv1beta3.TriggerConfig{
+					Cron: &rfv1beta3.CronTrigger{Location: "Asia/Shanghai", Args: json.RawMessage(args)},
+				},
+			},
+			Status: rfv1beta3.TriggerStatus{LastSuccessfulTime: &last},
+		}
+	}
+
+	// 2026-03-02 01:30 in Asia/Shanghai
+	ts := "2026-03-01T17:30:00Z"
+	tests := []struct {
+		name     string
+		args     string
+		key      string
+		want     interface{}
+		redacted bool
+		wantErr  bool
+	}{
+		{"plain", `{"a":"b"}`, "a", "b", false, false},
+		{"local date", `{"d":"{{ .Time | format \"date\" }}"}`, "d", "2026-03-02", false, false},
+		{"yesterday", `{"d":"{{ .Time | yesterday | format \"RFC3339\" }}"}`, "d", "2026-03-01T00:00:00+08:00", false, false},
+		{"last success", `{"d":"{{ .LastSuccessfulTime | in \"UTC\" | format \"15:04\" }}"}`, "d", "16:00", false, false},
+		{"nested", `{"l":[{"v":"{{ .TriggerName }}"}]}`, "l", []interface{}{map[string]interface{}{"v": "nightly"}}, false, false},
+		{"configmap", `{"c":"{{ configMap \"etl\" \"bucket\" }}"}`, "c", "warehouse", false, false},
+		{"secret", `{"s":"{{ secret \"etl\" \"token\" }}"}`, "s", "s3cr3t", true, false},
+		{"missing key", `{"c":"{{ configMap \"etl\" \"nope\" }}"}`, "", nil, false, true},
+		{"bad template", `{"c":"{{ .Nope }}"}`, "", nil, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bts, redactedBts, err := r.renderArgs(newTrigger(tt.args), ts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("renderArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			var got map[string]interface{}
+			if err := json.Unmarshal(bts, &got); err != nil {
+				t.Fatal(err)
+			}
+			if got["$time"] != ts || got["$triggerName"] != "nightly" {
+				t.Errorf("renderArgs() misses $time or $triggerName, %v", got)
+			}
+			if g, w := mustJSON(got[tt.key]), mustJSON(tt.want); g != w {
+				t.Errorf("args[%q] = %s, want %s", tt.key, g, w)
+			}
+
+			var redacted map[string]interface{}
+			if err := json.Unmarshal(redactedBts, &redacted); err != nil {
+				t.Fatal(err)
+			}
+			want := tt.want
+			if tt.redacted {
+				want = redactedValue
+			}
+			if g, w := mustJSON(redacted[tt.key]), mustJSON(want); g != w {
+				t.Errorf("redacted[%q] = %s, want %s", tt.key, g, w)
+			}
+			if redacted["$time"] != ts {
+				t.Errorf("redacted args misses $time, %v", redacted)
+			}
+		})
+	}
+}
+
+func mustJSON(v interface{}) string {
+	bts, _ := json.Marshal(v)
+	return string(bts)
+}
@@ -10,6 +10,8 @@ import (
 	apiv1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	k8sinformers "k8s.io/client-go/informers"
+	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog"
@@ -33,6 +35,9 @@ type Operator struct {
 	liveTasks operators.LiveTaskStore
 
 	scheduled observer.Property
+
+	configMapLister corelisters.ConfigMapLister
+	secretLister    corelisters.SecretLister
 }
 
 // Type name for rpc trigger
@@ -44,6 +49,7 @@ func NewOperator(
 	cfg *rest.Config,
 	rclient refunc.Interface,
 	rfInformers informers.SharedInformerFactory,
+	kubeInformers k8sinformers.SharedInformerFactory,
 ) (*Operator, error) {
 	base, err := operators.NewBaseOperator(cfg, rclient, rfInformers)
 	if err != nil {
@@ -55,7 +61,14 @@ func NewOperator(
 		ctx:          ctx,
 		liveTasks:    operators.NewLiveTaskStore(),
 		scheduled:    observer.NewProperty(nil),
+
+		configMapLister: kubeInformers.Core().V1().ConfigMaps().Lister(),
+		secretLister:    kubeInformers.Core().V1().Secrets().Lister(),
 	}
+	r.WantedInformers = append(r.WantedInformers,
+		kubeInformers.Core().V1().ConfigMaps().Informer().HasSynced,
+		kubeInformers.Core().V1().Secrets().Informer().HasSynced,
+	)
 
 	return r, nil
 }
@@ -109,6 +122,7 @@ func (r *Operator) handleTriggerAdd(o interface{}) {
 		klog.Infof("(crontrigger) adding trigger %s", key)
 		r.scheduleTasks()
 	}
+	r.handleRunNow(trigger)
 }
 
 func (r *Operator) handleTriggerUpdate(oldObj, curObj interface{}) {
@@ -134,7 +148,13 @@ func (r *Operator) handleTriggerUpdate(oldObj, curObj interface{}) {
 		klog.Infof("(crontrigger) updating trigger %s", key)
 		r.triggers.Delete(key)
 		r.handleTriggerAdd(cur)
+		return
+	}
+	if old.Spec.Cron != nil && cur.Spec.Cron != nil && old.Spec.Cron.Suspend != cur.Spec.Cron.Suspend {
+		klog.Infof("(crontrigger) %s suspend: %v", k8sKey(cur), cur.Spec.Cron.Suspend)
+		r.scheduleTasks()
 	}
+	r.handleRunNow(cur)
 }
 
 func (r *Operator) handleTriggerDelete(o interface{}) {
@@ -170,11 +190,16 @@ func (r *Operator) scheduleTasks() {
 	r.triggers.Range(func(k, v interface{}) bool {
 		key := k.(string)
 		h := v.(*cronHandler)
+		if trigger, err := r.TriggerLister.Triggers(h.ns).Get(h.name); err == nil && suspended(trigger) {
+			h.reportNext(time.Time{})
+			return true
+		}
 		next, err := h.Next()
 		if err != nil {
 			klog.Errorf("(crontrigger) %s failed to schedule, %v", key, err)
 			return true
 		}
+		h.reportNext(next)
 		tkps = append(tkps, &timeKeyPair{next, key})
 		return true
 	})
@@ -258,15 +283,25 @@ func (r *Operator) runScheduledTasks(scheduledTasks []*timeKeyPair) {
 		h := val.(*cronHandler)
 
 		delta := now.Sub(tkp.t)
-		if delta > time.Minute {
-			klog.Warningf("(crontrigger) %s missed trigger, want %v, current %v", h.trKey, tkp.t, now.Truncate(time.Second))
-			continue
-		}
 		if delta < 0 {
 			break
 		}
+		h.fired(tkp.t)
+		trigger, err := r.TriggerLister.Triggers(h.ns).Get(h.name)
+		if err != nil {
+			klog.Warningf("(crontrigger) %s failed to get trigger, %v", h.trKey, err)
+			continue
+		}
+		if suspended(trigger) {
+			klog.Infof("(crontrigger) %s is suspended, skip run of %v", h.trKey, tkp.t)
+			continue
+		}
+		if deadline, ok := startingDeadline(trigger); ok && delta > deadline {
+			klog.Warningf("(crontrigger) %s missed trigger, want %v, current %v", h.trKey, tkp.t, now.Truncate(time.Second))
+			continue
+		}
 
-		// delta <= time.Minute, tirgger one task
+		// within the starting deadline, tirgger one task
 		go func() {
 			klog.Infof("(crontrigger) begin run cron %s task", tkp.key)
 			val.(*cronHandler).Run(tkp.t)
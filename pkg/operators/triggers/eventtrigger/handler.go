@@ -0,0 +1,196 @@
+package eventtrigger
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/klog"
+
+	nats "github.com/nats-io/nats.go"
+	"github.com/nats-io/nuid"
+	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
+	"github.com/refunc/refunc/pkg/client"
+	"github.com/refunc/refunc/pkg/messages"
+	"github.com/refunc/refunc/pkg/utils"
+)
+
+// Event is the args passed to funcs
+type Event struct {
+	// ID is Nats-Msg-Id of the message if set, otherwise the hash of data
+	ID string `json:"id"`
+	// Source is the name of func which publishes the event
+	Source string `json:"source"`
+	// Name is the subject after events.
+	Name    string          `json:"name"`
+	Subject string          `json:"subject"`
+	Time    string          `json:"time"`
+	Data    json.RawMessage `json:"data"`
+}
+
+type eventHandler struct {
+	trKey string
+	ns    string
+	name  string
+
+	middlewares []middleware
+
+	subs []*nats.Subscription
+
+	operator *Operator
+}
+
+func newEventHandler(r *Operator, trigger *rfv1beta3.Trigger) (*eventHandler, error) {
+	mws, err := parseMiddlewares(trigger.Spec.Event.Middlewares)
+	if err != nil {
+		return nil, err
+	}
+	return &eventHandler{
+		trKey:       k8sKey(trigger),
+		ns:          trigger.Namespace,
+		name:        trigger.Name,
+		middlewares: mws,
+		operator:    r,
+	}, nil
+}
+
+// subjects returns subjects of events subscribed by the trigger
+func (h *eventHandler) subjects() []string {
+	trigger, err := h.operator.TriggerLister.Triggers(h.ns).Get(h.name)
+	if err != nil {
+		return nil
+	}
+	alias := trigger.Spec.Event.Alias
+	if alias == "" {
+		alias = trigger.Name
+	}
+	sources := trigger.Spec.Event.Sources
+	if len(sources) == 0 {
+		sources = []string{"*"}
+	}
+	subjects := make([]string, 0, len(sources))
+	for _, src := range sources {
+		subjects = append(subjects, fmt.Sprintf("refunc.%s.%s.events.%s", h.ns, src, alias))
+	}
+	return subjects
+}
+
+func (h *eventHandler) start() error {
+	natsConn := client.GetNatsConn(h.operator.ctx)
+	// replicas of operator share events in a queue group
+	queue := "_refunc.eventtriggers." + h.ns + "." + h.name
+	for _, subject := range h.subjects() {
+		sub, err := natsConn.QueueSubscribe(subject, queue, h.handleMsg)
+		if err != nil {
+			h.stop()
+			return err
+		}
+		h.subs = append(h.subs, sub)
+	}
+	return nil
+}
+
+func (h *eventHandler) stop() {
+	for _, sub := range h.subs {
+		if err := sub.Unsubscribe(); err != nil {
+			klog.Warningf("(h) %s failed to unsubscribe %s, %v", h.trKey, sub.Subject, err)
+		}
+	}
+	h.subs = nil
+}
+
+func (h *eventHandler) handleMsg(msg *nats.Msg) {
+	event := newEvent(msg)
+	for _, mw := range h.middlewares {
+		ok, err := mw(event)
+		if err != nil {
+			klog.Errorf("(h) %s failed to process event %s, %v", h.trKey, event.ID, err)
+			return
+		}
+		if !ok {
+			klog.V(4).Infof("(h) %s dropped event %s", h.trKey, event.ID)
+			return
+		}
+	}
+
+	// blocks the subscription when too many funcs are running
+	select {
+	case h.operator.inflight <- struct{}{}:
+	case <-h.operator.ctx.Done():
+		return
+	}
+	go func() {
+		defer func() { <-h.operator.inflight }()
+		h.invoke(event)
+	}()
+}
+
+// newEvent parses event from msg
+func newEvent(msg *nats.Msg) *Event {
+	// refunc.<ns>.<source>.events.<name>
+	parts := strings.SplitN(msg.Subject, ".", 5)
+	event := &Event{
+		Subject: msg.Subject,
+		Time:    time.Now().Format(time.RFC3339Nano),
+	}
+	if len(parts) == 5 {
+		event.Source, event.Name = parts[2], parts[4]
+	}
+	if msg.Header != nil {
+		event.ID = msg.Header.Get(nats.MsgIdHdr)
+	}
+	if event.ID == "" {
+		event.ID = utils.GenID([]byte(msg.Subject), msg.Data)
+	}
+	if json.Valid(msg.Data) {
+		event.Data = json.RawMessage(msg.Data)
+	} else {
+		event.Data = messages.MustFromObject(string(msg.Data))
+	}
+	return event
+}
+
+func (h *eventHandler) invoke(event *Event) {
+	defer func() {
+		if re := recover(); re != nil {
+			utils.LogTraceback(re, 5, klog.V(1))
+		}
+	}()
+
+	trigger, err := h.operator.TriggerLister.Triggers(h.ns).Get(h.name)
+	if err != nil {
+		klog.Errorf("(h) %s failed to get trigger, %v", h.trKey, err)
+		return
+	}
+	fndef, err := h.operator.ResolveFuncdef(trigger)
+	if err != nil {
+		klog.Errorf("(h) %s failed to get fundef, %v", h.trKey, err)
+		return
+	}
+
+	var timeout = messages.DefaultJobTimeout
+	if fndef.Spec.Runtime != nil && fndef.Spec.Runtime.Timeout > 0 {
+		timeout = time.Second * time.Duration(fndef.Spec.Runtime.Timeout)
+	}
+	ctx := h.operator.ctx
+	ctx = client.WithLogger(ctx, klog.V(1))
+	ctx = client.WithTimeoutHint(ctx, timeout)
+	ctx = client.WithLoggingHint(ctx, false)
+
+	endpoint := fndef.Namespace + "/" + fndef.Name
+	taskr, err := client.NewTaskResolver(ctx, endpoint, &messages.InvokeRequest{
+		Args:      messages.MustFromObject(event),
+		RequestID: nuid.Next(),
+	})
+	if err != nil {
+		klog.Errorf("(h) %s failed to invoke %s for event %s, %v", h.trKey, endpoint, event.ID, err)
+		return
+	}
+	<-taskr.Done()
+	if _, err := taskr.Result(); err != nil {
+		klog.Errorf("(h) %s failed to handle event %s, %v", taskr.ID(), event.ID, err)
+		return
+	}
+	klog.V(3).Infof("(h) %s handled event %s", taskr.ID(), event.ID)
+}
@@ -0,0 +1,162 @@
+package eventtrigger
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// middleware processes an event before invocation, returns false to drop it
+type middleware func(event *Event) (bool, error)
+
+// middleware creators by name, the arg is the string after the first colon
+var middlewareCreators = map[string]func(arg string) (middleware, error){
+	"filter":    newFilter,
+	"transform": newTransform,
+	"dedupe":    newDedupe,
+}
+
+func parseMiddlewares(specs []string) ([]middleware, error) {
+	var mws []middleware
+	for _, spec := range specs {
+		name, arg := spec, ""
+		if i := strings.Index(spec, ":"); i >= 0 {
+			name, arg = spec[:i], spec[i+1:]
+		}
+		create, ok := middlewareCreators[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown middleware %q", name)
+		}
+		mw, err := create(arg)
+		if err != nil {
+			return nil, fmt.Errorf("middleware %s: %v", name, err)
+		}
+		mws = append(mws, mw)
+	}
+	return mws, nil
+}
+
+// templateData is the data of templates in middlewares
+//
+//	filter:{{ eq .Data.type "created" }}
+//	transform:{"id": {{ .Data.order.id | json }}, "source": {{ .Source | json }}}
+type templateData struct {
+	ID      string
+	Source  string
+	Name    string
+	Subject string
+	Time    string
+	// Data is the decoded json data of event
+	Data interface{}
+}
+
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		bts, err := json.Marshal(v)
+		return string(bts), err
+	},
+}
+
+// newTemplate returns a function renders event by the template of arg
+func newTemplate(arg string) (func(*Event) (string, error), error) {
+	tmpl, err := template.New("middleware").Funcs(templateFuncs).Parse(arg)
+	if err != nil {
+		return nil, err
+	}
+	return func(event *Event) (string, error) {
+		data := &templateData{
+			ID:      event.ID,
+			Source:  event.Source,
+			Name:    event.Name,
+			Subject: event.Subject,
+			Time:    event.Time,
+		}
+		if err := json.Unmarshal(event.Data, &data.Data); err != nil {
+			return "", err
+		}
+		var sb strings.Builder
+		if err := tmpl.Execute(&sb, data); err != nil {
+			return "", err
+		}
+		return sb.String(), nil
+	}, nil
+}
+
+func newFilter(arg string) (middleware, error) {
+	render, err := newTemplate(arg)
+	if err != nil {
+		return nil, err
+	}
+	return func(event *Event) (bool, error) {
+		out, err := render(event)
+		if err != nil {
+			return false, err
+		}
+		return strings.TrimSpace(out) == "true", nil
+	}, nil
+}
+
+func newTransform(arg string) (middleware, error) {
+	render, err := newTemplate(arg)
+	if err != nil {
+		return nil, err
+	}
+	return func(event *Event) (bool, error) {
+		out, err := render(event)
+		if err != nil {
+			return false, err
+		}
+		if !json.Valid([]byte(out)) {
+			return false, fmt.Errorf("transformed data is not a valid json, %q", out)
+		}
+		event.Data = json.RawMessage(out)
+		return true, nil
+	}, nil
+}
+
+// deduper remembers ids of events in a window, the ids are local to the replica,
+// an event redelivered to another replica of the queue group is not deduped.
+type deduper struct {
+	mu        sync.Mutex
+	window    time.Duration
+	seen      map[string]time.Time
+	lastSweep time.Time
+}
+
+func newDedupe(arg string) (middleware, error) {
+	window, err := time.ParseDuration(arg)
+	if err != nil {
+		return nil, err
+	}
+	if window <= 0 {
+		return nil, fmt.Errorf("window must be positive, got %v", window)
+	}
+	d := &deduper{window: window, seen: make(map[string]time.Time)}
+	return func(event *Event) (bool, error) {
+		return !d.seenBefore(event.ID, time.Now()), nil
+	}, nil
+}
+
+// seenBefore records id, returns true if id is seen in the window
+func (d *deduper) seenBefore(id string, now time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if now.Sub(d.lastSweep) > d.window {
+		for k, t := range d.seen {
+			if now.Sub(t) > d.window {
+				delete(d.seen, k)
+			}
+		}
+		d.lastSweep = now
+	}
+
+	if t, ok := d.seen[id]; ok && now.Sub(t) <= d.window {
+		return true
+	}
+	d.seen[id] = now
+	return false
+}
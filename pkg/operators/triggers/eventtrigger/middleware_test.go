@@ -0,0 +1,108 @@
+package eventtrigger
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	nats "github.com/nats-io/nats.go"
+)
+
+func Test_parseMiddlewares(t *testing.T) {
+	tests := []struct {
+		name        string
+		middlewares []string
+		data        string
+		wantOK      []bool
+		wantData    string
+		wantErr     bool
+	}{
+		{
+			name:        "filter",
+			middlewares: []string{`filter:{{ eq .Data.type "created" }}`},
+			data:        `{"type":"created"}`,
+			wantOK:      []bool{true},
+			wantData:    `{"type":"created"}`,
+		},
+		{
+			name:        "filter drops",
+			middlewares: []string{`filter:{{ eq .Data.type "created" }}`},
+			data:        `{"type":"deleted"}`,
+			wantOK:      []bool{false},
+		},
+		{
+			name:        "transform",
+			middlewares: []string{`transform:{"id": {{ .Data.order.id | json }}, "from": {{ .Source | json }}}`},
+			data:        `{"order":{"id":"o1"}}`,
+			wantOK:      []bool{true},
+			wantData:    `{"id": "o1", "from": "orders"}`,
+		},
+		{
+			name:        "dedupe",
+			middlewares: []string{"dedupe:1m"},
+			data:        `{}`,
+			wantOK:      []bool{true, false},
+			wantData:    `{}`,
+		},
+		{
+			name:        "unknown",
+			middlewares: []string{"retry:3"},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mws, err := parseMiddlewares(tt.middlewares)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseMiddlewares() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for i, want := range tt.wantOK {
+				event := newEvent(&nats.Msg{Subject: "refunc.ns.orders.events.created", Data: []byte(tt.data)})
+				var ok bool
+				for _, mw := range mws {
+					if ok, err = mw(event); err != nil || !ok {
+						break
+					}
+				}
+				if err != nil {
+					t.Fatalf("#%d middleware error = %v", i, err)
+				}
+				if ok != want {
+					t.Errorf("#%d ok = %v, want %v", i, ok, want)
+				}
+				if ok && string(event.Data) != tt.wantData {
+					t.Errorf("#%d data = %s, want %s", i, event.Data, tt.wantData)
+				}
+			}
+		})
+	}
+}
+
+func Test_newEvent(t *testing.T) {
+	msg := nats.NewMsg("refunc.ns.orders.events.order.created")
+	msg.Header.Set(nats.MsgIdHdr, "e1")
+	msg.Data = []byte("plain")
+
+	event := newEvent(msg)
+	if event.ID != "e1" || event.Source != "orders" || event.Name != "order.created" {
+		t.Errorf("newEvent() = %+v", event)
+	}
+	var data string
+	if err := json.Unmarshal(event.Data, &data); err != nil || data != "plain" {
+		t.Errorf("data = %s, %v", event.Data, err)
+	}
+}
+
+func Test_deduper(t *testing.T) {
+	d := &deduper{window: time.Minute, seen: make(map[string]time.Time)}
+	now := time.Now()
+	if d.seenBefore("a", now) {
+		t.Error("a should not be seen")
+	}
+	if !d.seenBefore("a", now.Add(time.Second)) {
+		t.Error("a should be seen")
+	}
+	if d.seenBefore("a", now.Add(2*time.Minute)) {
+		t.Error("a should be expired")
+	}
+}
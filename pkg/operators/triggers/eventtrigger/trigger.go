@@ -0,0 +1,162 @@
+package eventtrigger
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+
+	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
+	"github.com/refunc/refunc/pkg/client"
+	refunc "github.com/refunc/refunc/pkg/generated/clientset/versioned"
+	informers "github.com/refunc/refunc/pkg/generated/informers/externalversions"
+	operators "github.com/refunc/refunc/pkg/operators"
+	"github.com/refunc/refunc/pkg/utils"
+)
+
+// Operator subscribes events for event triggers, and invokes funcs per event
+type Operator struct {
+	*operators.BaseOperator
+
+	// MaxInflight is the max number of concurrent invocations, defaults to 256
+	MaxInflight int
+	inflight    chan struct{}
+
+	ctx context.Context
+
+	triggers sync.Map
+}
+
+// Type name for event trigger
+const Type = "eventtrigger"
+
+// NewOperator creates a new event trigger operator
+func NewOperator(
+	ctx context.Context,
+	cfg *rest.Config,
+	rclient refunc.Interface,
+	rfInformers informers.SharedInformerFactory,
+) (*Operator, error) {
+	base, err := operators.NewBaseOperator(cfg, rclient, rfInformers)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Operator{
+		BaseOperator: base,
+		MaxInflight:  256,
+		ctx:          ctx,
+	}
+
+	return r, nil
+}
+
+// Run will not return until stopC is closed.
+func (r *Operator) Run(stopC <-chan struct{}) {
+	defer func() {
+		if re := recover(); re != nil {
+			utils.LogTraceback(re, 4, klog.V(1))
+		}
+	}()
+
+	if client.GetNatsConn(r.ctx) == nil {
+		klog.Error("(eventtrigger) nats connection is required")
+		return
+	}
+	if r.MaxInflight <= 0 {
+		r.MaxInflight = 256
+	}
+	r.inflight = make(chan struct{}, r.MaxInflight)
+
+	r.RefuncInformers.Refunc().V1beta3().Triggers().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    r.handleTriggerAdd,
+		UpdateFunc: r.handleTriggerUpdate,
+		DeleteFunc: r.handleTriggerDelete,
+	})
+
+	klog.Info("(eventtrigger) starting event trigger operator")
+
+	if !r.BaseOperator.WaitForCacheSync(stopC) {
+		klog.Error("(eventtrigger) cannot fully sync resources")
+		return
+	}
+
+	<-stopC
+
+	r.triggers.Range(func(k, v interface{}) bool {
+		v.(*eventHandler).stop()
+		return true
+	})
+	klog.Info("(eventtrigger) shuting down event trigger operator")
+}
+
+func (r *Operator) handleTriggerAdd(o interface{}) {
+	trigger := o.(*rfv1beta3.Trigger)
+	if trigger.Spec.Type != Type {
+		// skip other triggers
+		return
+	}
+
+	key := k8sKey(trigger)
+	if trigger.Spec.Event == nil {
+		klog.Errorf("(eventtrigger) %s event is empty", key)
+		return
+	}
+	if _, ok := r.triggers.Load(key); ok {
+		return
+	}
+
+	h, err := newEventHandler(r, trigger)
+	if err != nil {
+		klog.Errorf("(eventtrigger) %s is invalid, %v", key, err)
+		return
+	}
+	if err := h.start(); err != nil {
+		klog.Errorf("(eventtrigger) %s failed to subscribe, %v", key, err)
+		return
+	}
+	r.triggers.Store(key, h)
+	klog.Infof("(eventtrigger) adding trigger %s, subscribed %v", key, h.subjects())
+}
+
+func (r *Operator) handleTriggerUpdate(oldObj, curObj interface{}) {
+	old := oldObj.(*rfv1beta3.Trigger)
+	cur := curObj.(*rfv1beta3.Trigger)
+
+	// Periodic resync may resend the deployment without changes in-between.
+	// Also breaks loops created by updating the resource ourselves.
+	if old.ResourceVersion == cur.ResourceVersion {
+		return
+	}
+	if old.Spec.Type != cur.Spec.Type || !reflect.DeepEqual(old.Spec.Event, cur.Spec.Event) {
+		// unsubscribe and resubscribe
+		r.handleTriggerDelete(old)
+		r.handleTriggerAdd(cur)
+	}
+}
+
+func (r *Operator) handleTriggerDelete(o interface{}) {
+	trigger, ok := o.(*rfv1beta3.Trigger)
+	if !ok {
+		// it's cache.DeletedFinalStateUnknown
+		return
+	}
+	if trigger.Spec.Type != Type {
+		// skip other triggers
+		return
+	}
+
+	key := k8sKey(trigger)
+	if val, ok := r.triggers.LoadAndDelete(key); ok {
+		klog.Infof("(eventtrigger) deleting trigger %s", key)
+		val.(*eventHandler).stop()
+	}
+}
+
+func k8sKey(o metav1.Object) string {
+	return o.GetNamespace() + "/" + o.GetName()
+}
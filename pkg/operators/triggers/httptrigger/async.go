@@ -0,0 +1,343 @@
+package httptrigger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog"
+
+	"github.com/gorilla/mux"
+	minio "github.com/minio/minio-go"
+	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
+	"github.com/refunc/refunc/pkg/client"
+	"github.com/refunc/refunc/pkg/env"
+	"github.com/refunc/refunc/pkg/messages"
+	"github.com/refunc/refunc/pkg/utils"
+)
+
+const (
+	invocationTypeHeader = "X-Refunc-Invocation-Type"
+	invocationTypeEvent  = "Event"
+
+	s3Prefix = "_system/httptriggers"
+
+	// logs of a running task kept in memory
+	maxAsyncLogsSize = messages.MaxPayloadSize
+)
+
+// well known status of async tasks
+const (
+	taskStatusRunning   = "running"
+	taskStatusSucceeded = "succeeded"
+	taskStatusFailed    = "failed"
+)
+
+// TaskStatus is the document returns by GET /ns/refunc-name/_tasks/{id}
+type TaskStatus struct {
+	ID         string                 `json:"id"`
+	Owner      string                 `json:"owner,omitempty"`
+	Status     string                 `json:"status"`
+	CreatedAt  time.Time              `json:"createdAt"`
+	FinishedAt *time.Time             `json:"finishedAt,omitempty"`
+	Error      *messages.ErrorMessage `json:"error,omitempty"`
+	Logs       string                 `json:"logs,omitempty"`
+	Result     json.RawMessage        `json:"result,omitempty"`
+}
+
+// asyncTask tracks an invocation until its outputs are persisted
+type asyncTask struct {
+	sync.Mutex
+
+	id        string
+	owner     string
+	createdAt time.Time
+	logs      []byte
+	truncated bool
+
+	// set once finished
+	status     *TaskStatus
+	resultData []byte
+}
+
+// taskStore persists outputs of async tasks
+type taskStore interface {
+	Put(key, contentType string, data []byte) error
+	// Get returns errTaskNotFound if key does not exist
+	Get(key string) ([]byte, error)
+}
+
+var errTaskNotFound = errors.New("h: task not found")
+
+// s3TaskStore saves tasks to the global bucket
+type s3TaskStore struct{}
+
+func (s3TaskStore) Put(key, contentType string, data []byte) error {
+	_, err := env.GlobalMinioClient().PutObject(
+		env.GlobalBucket, key,
+		bytes.NewReader(data), int64(len(data)),
+		minio.PutObjectOptions{ContentType: contentType},
+	)
+	return err
+}
+
+func (s3TaskStore) Get(key string) ([]byte, error) {
+	obj, err := env.GlobalMinioClient().GetObject(env.GlobalBucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer obj.Close()
+	bts, err := ioutil.ReadAll(obj)
+	if err != nil && minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return nil, errTaskNotFound
+	}
+	return bts, err
+}
+
+func isAsyncRequest(req *http.Request) bool {
+	return strings.EqualFold(req.Header.Get(invocationTypeHeader), invocationTypeEvent)
+}
+
+// asyncTaskOwner returns the principal who owns tasks started with authorizer
+func asyncTaskOwner(authorizer *RequestContextAuthorizer) string {
+	if authorizer == nil {
+		return ""
+	}
+	return authorizer.Type + "/" + authorizer.PrincipalID
+}
+
+// asyncTaskID returns id of an async task, a retried request with the same X-Request-ID,
+// owner and payload will not start a new invocation.
+func (t *httpHandler) asyncTaskID(owner string, request *messages.InvokeRequest) string {
+	return utils.GenID([]byte(t.fndKey+"\n"+owner+"\n"+request.RequestID+"\n"), request.Args)
+}
+
+// startAsyncTask starts an invocation in background, its logs and result are saved to object store,
+// release is called once the invocation finishes. Persisted tasks are looked up only for idempotent
+// requests, which are sent with X-Request-ID.
+func (t *httpHandler) startAsyncTask(
+	fndef *rfv1beta3.Funcdef,
+	trigger *rfv1beta3.Trigger,
+	request *messages.InvokeRequest,
+	owner string,
+	idempotent bool,
+	release func(),
+) (string, error) {
+	id := t.asyncTaskID(owner, request)
+	if _, loaded := t.operator.asyncTasks.Load(id); loaded {
+		release()
+		return id, nil
+	}
+	// finished tasks are only in store
+	if idempotent {
+		if _, err := t.operator.asyncStore.Get(t.asyncTaskKey(id, "status.json")); err != errTaskNotFound {
+			release()
+			if err != nil {
+				return "", err
+			}
+			return id, nil
+		}
+	}
+
+	request.Options["logging"] = true
+	taskr, err := t.ensureTask(id, fndef, trigger, request, nil)
+	if err != nil {
+		release()
+		return "", err
+	}
+
+	at := &asyncTask{id: id, owner: owner, createdAt: time.Now()}
+	if _, loaded := t.operator.asyncTasks.LoadOrStore(id, at); loaded {
+		release()
+		return id, nil
+	}
+	go t.watchAsyncTask(at, taskr, release)
+	return id, nil
+}
+
+func (t *httpHandler) watchAsyncTask(at *asyncTask, taskr client.TaskResolver, release func()) {
+	defer func() {
+		if re := recover(); re != nil {
+			utils.LogTraceback(re, 5, klog.V(1))
+		}
+		t.operator.asyncTasks.Delete(at.id)
+		release()
+	}()
+
+	logs := taskr.LogObserver()
+	collectLogs := func() {
+		at.Lock()
+		defer at.Unlock()
+		for logs.HasNext() {
+			line := []byte(logs.Next().(string))
+			if len(at.logs)+len(line) > maxAsyncLogsSize {
+				at.truncated = true
+				continue
+			}
+			at.logs = append(at.logs, line...)
+			at.logs = append(at.logs, messages.TokenCRLF...)
+		}
+	}
+	for running := true; running; {
+		select {
+		case <-logs.Changes():
+		case <-taskr.Done():
+			running = false
+		}
+		collectLogs()
+	}
+
+	bts, err := taskr.Result()
+	now := time.Now()
+
+	at.Lock()
+	status := &TaskStatus{
+		ID:         at.id,
+		Owner:      at.owner,
+		Status:     taskStatusSucceeded,
+		CreatedAt:  at.createdAt,
+		FinishedAt: &now,
+	}
+	if err != nil {
+		klog.Errorf("(h) %s async task %s failed, %v", t.fndKey, at.id, err)
+		status.Status = taskStatusFailed
+		status.Error = messages.GetErrorMessage(err)
+		bts = messages.GetErrActionBytes(err)
+	}
+	if at.truncated {
+		at.logs = append(at.logs, []byte("...logs truncated")...)
+	}
+	at.status, at.resultData = status, bts
+	logsData := at.logs
+	at.Unlock()
+
+	t.persistAsyncTask(status, logsData, bts)
+}
+
+func (t *httpHandler) asyncTaskKey(id, name string) string {
+	return env.KeyWithinScope(filepath.Join(t.ns, s3Prefix, t.name, "tasks", id, name))
+}
+
+func (t *httpHandler) persistAsyncTask(status *TaskStatus, logs, result []byte) {
+	put := func(name, contentType string, data []byte) {
+		key := t.asyncTaskKey(status.ID, name)
+		if err := t.operator.asyncStore.Put(key, contentType, data); err != nil {
+			klog.Errorf("(h) %s failed to write %q, %v", t.fndKey, key, err)
+		}
+	}
+	if len(logs) > 0 {
+		put("logs.log", "text/plain; charset=UTF-8", logs)
+	}
+	put("result.json", jsonCT, result)
+	// status is the last one, as it marks the task is persisted
+	put("status.json", jsonCT, messages.MustFromObject(status))
+}
+
+func (t *httpHandler) loadAsyncTask(id string) (*TaskStatus, error) {
+	get := func(name string) ([]byte, error) {
+		return t.operator.asyncStore.Get(t.asyncTaskKey(id, name))
+	}
+
+	bts, err := get("status.json")
+	if err != nil {
+		return nil, err
+	}
+	var status TaskStatus
+	if err := json.Unmarshal(bts, &status); err != nil {
+		return nil, err
+	}
+	if logs, err := get("logs.log"); err == nil {
+		status.Logs = string(logs)
+	}
+	if status.Status == taskStatusSucceeded {
+		if result, err := get("result.json"); err == nil {
+			status.Result = toRawMessage(result)
+		}
+	}
+	return &status, nil
+}
+
+// handleAsyncInvoke replies 202 with the id of started task
+func (t *httpHandler) handleAsyncInvoke(
+	rw http.ResponseWriter,
+	req *http.Request,
+	fndef *rfv1beta3.Funcdef,
+	trigger *rfv1beta3.Trigger,
+	request *messages.InvokeRequest,
+	authorizer *RequestContextAuthorizer,
+	release func(),
+) {
+	idempotent := req.Header.Get("X-Request-ID") != ""
+	id, err := t.startAsyncTask(fndef, trigger, request, asyncTaskOwner(authorizer), idempotent, release)
+	if err != nil {
+		klog.Errorf("(h) %s failed to start async task, %v", t.fndKey, err)
+		writeHTTPError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+	rw.Header().Set("Content-Type", jsonCT)
+	rw.Header().Set("Location", "/"+t.fndKey+"/_tasks/"+id)
+	rw.WriteHeader(http.StatusAccepted)
+	fmt.Fprintf(rw, "{\"taskId\":%q}\r\n", id)
+}
+
+// handleTaskStatus serves GET /ns/refunc-name/_tasks/{id}
+func (t *httpHandler) handleTaskStatus(rw http.ResponseWriter, req *http.Request) {
+	defer func() {
+		if re := recover(); re != nil {
+			utils.LogTraceback(re, 4, klog.V(1))
+			writeHTTPError(rw, http.StatusInternalServerError, fmt.Sprintf("%v", re))
+		}
+	}()
+
+	trigger, err := t.operator.TriggerLister.Triggers(t.ns).Get(t.name)
+	if err != nil {
+		writeHTTPError(rw, http.StatusBadRequest, err.Error())
+		return
+	}
+	authorizer, err := t.authenticate(trigger, req)
+	if err != nil {
+		writeHTTPError(rw, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	id := mux.Vars(req)["id"]
+	owner := asyncTaskOwner(authorizer)
+
+	var status *TaskStatus
+	if val, ok := t.operator.asyncTasks.Load(id); ok {
+		at := val.(*asyncTask)
+		at.Lock()
+		if at.status != nil {
+			cp := *at.status
+			status = &cp
+			if status.Status == taskStatusSucceeded {
+				status.Result = toRawMessage(at.resultData)
+			}
+		} else {
+			status = &TaskStatus{ID: id, Owner: at.owner, Status: taskStatusRunning, CreatedAt: at.createdAt}
+		}
+		status.Logs = string(at.logs)
+		at.Unlock()
+	} else {
+		status, err = t.loadAsyncTask(id)
+		if err != nil && err != errTaskNotFound {
+			writeHTTPError(rw, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	// tasks of others are hidden
+	if status == nil || status.Owner != owner {
+		writeHTTPError(rw, http.StatusNotFound, fmt.Sprintf("task %q not found", id))
+		return
+	}
+
+	rw.Header().Set("Content-Type", jsonCT)
+	rw.Write(append(messages.MustFromObject(status), messages.TokenCRLF...)) // nolint:errcheck
+}
@@ -0,0 +1,179 @@
+package httptrigger
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/gorilla/mux"
+	observer "github.com/refunc/go-observer"
+	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
+	"github.com/refunc/refunc/pkg/client"
+	rflistersv1 "github.com/refunc/refunc/pkg/generated/listers/refunc/v1beta3"
+	"github.com/refunc/refunc/pkg/messages"
+	"github.com/refunc/refunc/pkg/operators"
+)
+
+type memTaskStore struct {
+	sync.Mutex
+	objects      map[string][]byte
+	contentTypes map[string]string
+}
+
+func (s *memTaskStore) Put(key, contentType string, data []byte) error {
+	s.Lock()
+	defer s.Unlock()
+	s.objects[key], s.contentTypes[key] = data, contentType
+	return nil
+}
+
+func (s *memTaskStore) Get(key string) ([]byte, error) {
+	s.Lock()
+	defer s.Unlock()
+	if data, ok := s.objects[key]; ok {
+		return data, nil
+	}
+	return nil, errTaskNotFound
+}
+
+func newAsyncTestHandler() (*httpHandler, *memTaskStore) {
+	triggers := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	triggers.Add(&rfv1beta3.Trigger{ // nolint:errcheck
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "fn"},
+		Spec:       rfv1beta3.TriggerSpec{FuncName: "fn", Type: Type},
+	})
+	store := &memTaskStore{objects: make(map[string][]byte), contentTypes: make(map[string]string)}
+	r := &Operator{
+		BaseOperator: &operators.BaseOperator{TriggerLister: rflistersv1.NewTriggerLister(triggers)},
+		ctx:          context.Background(),
+		liveTasks:    operators.NewLiveTaskStore(),
+		asyncStore:   store,
+	}
+	return &httpHandler{fndKey: "ns/fn", ns: "ns", name: "fn", operator: r}, store
+}
+
+func Test_httpHandler_handleAsyncInvoke(t *testing.T) {
+	h, store := newAsyncTestHandler()
+	task := &fakeTask{
+		done: make(chan struct{}),
+		logs: observer.NewProperty(nil),
+		msgs: observer.NewProperty(nil),
+		data: []byte(`{"ok":true}`),
+	}
+	newRequest := func() *messages.InvokeRequest {
+		return &messages.InvokeRequest{RequestID: "req", Options: map[string]interface{}{}}
+	}
+	newHTTPRequest := func() *http.Request {
+		req := httptest.NewRequest("POST", "/ns/fn", nil)
+		req.Header.Set("X-Request-ID", "req")
+		return req
+	}
+	id := h.asyncTaskID("", newRequest())
+	h.operator.liveTasks.GetOrCreateTask(id, func() (client.TaskResolver, error) { return task, nil }) // nolint:errcheck
+
+	released := make(chan struct{})
+	rw := httptest.NewRecorder()
+	h.handleAsyncInvoke(rw, newHTTPRequest(), &rfv1beta3.Funcdef{}, &rfv1beta3.Trigger{}, newRequest(), nil, func() { close(released) })
+	if rw.Code != http.StatusAccepted || rw.Header().Get("Location") != "/ns/fn/_tasks/"+id {
+		t.Fatalf("handleAsyncInvoke() = %d, Location %q", rw.Code, rw.Header().Get("Location"))
+	}
+	select {
+	case <-released:
+		t.Fatal("in-flight slot is released before the task finishes")
+	default:
+	}
+
+	close(task.done)
+	select {
+	case <-released:
+	case <-time.After(time.Second):
+		t.Fatal("in-flight slot is not released once the task finishes")
+	}
+	// persisted before the slot is released
+	resultKey := h.asyncTaskKey(id, "result.json")
+	if ct := store.contentTypes[resultKey]; !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("result.json is stored as %q", ct)
+	}
+	if _, err := store.Get(h.asyncTaskKey(id, "status.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	// retried after the task is persisted, no task is started
+	h.operator.liveTasks.Delete(id)
+	rw = httptest.NewRecorder()
+	h.handleAsyncInvoke(rw, newHTTPRequest(), &rfv1beta3.Funcdef{}, &rfv1beta3.Trigger{}, newRequest(), nil, func() {})
+	if rw.Code != http.StatusAccepted {
+		t.Errorf("retried handleAsyncInvoke() = %d, %s", rw.Code, rw.Body.String())
+	}
+	if _, has, _ := h.operator.liveTasks.Get(id); has {
+		t.Error("retried request starts a new task")
+	}
+}
+
+func Test_httpHandler_asyncTaskID(t *testing.T) {
+	h := &httpHandler{fndKey: "ns/fn"}
+	newRequest := func(args string) *messages.InvokeRequest {
+		return &messages.InvokeRequest{RequestID: "req", Args: json.RawMessage(args)}
+	}
+	id := h.asyncTaskID("jwt/alice", newRequest(`{"a":1}`))
+	if h.asyncTaskID("jwt/alice", newRequest(`{"a":1}`)) != id {
+		t.Error("retried request gets a new task id")
+	}
+	if h.asyncTaskID("jwt/bob", newRequest(`{"a":1}`)) == id {
+		t.Error("requests of other principal share the task")
+	}
+	if h.asyncTaskID("jwt/alice", newRequest(`{"a":2}`)) == id {
+		t.Error("requests with other payload share the task")
+	}
+}
+
+func Test_httpHandler_handleTaskStatus(t *testing.T) {
+	h, store := newAsyncTestHandler()
+	h.operator.asyncTasks.Store("running", &asyncTask{id: "running", logs: []byte("hello")})
+	finished := &TaskStatus{ID: "finished", Status: taskStatusSucceeded}
+	store.Put(h.asyncTaskKey("finished", "status.json"), jsonCT, messages.MustFromObject(finished)) // nolint:errcheck
+	store.Put(h.asyncTaskKey("finished", "result.json"), jsonCT, []byte(`{"ok":true}`))             // nolint:errcheck
+	others := &TaskStatus{ID: "others", Owner: "jwt/alice", Status: taskStatusSucceeded}
+	store.Put(h.asyncTaskKey("others", "status.json"), jsonCT, messages.MustFromObject(others)) // nolint:errcheck
+
+	tests := []struct {
+		id     string
+		code   int
+		status string
+		result string
+		logs   string
+	}{
+		{"running", http.StatusOK, taskStatusRunning, "", "hello"},
+		{"finished", http.StatusOK, taskStatusSucceeded, `{"ok":true}`, ""},
+		{"unknown", http.StatusNotFound, "", "", ""},
+		{"others", http.StatusNotFound, "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			req := mux.SetURLVars(httptest.NewRequest("GET", "/ns/fn/_tasks/"+tt.id, nil), map[string]string{"id": tt.id})
+			rw := httptest.NewRecorder()
+			h.handleTaskStatus(rw, req)
+			if rw.Code != tt.code {
+				t.Fatalf("handleTaskStatus() = %d, want %d", rw.Code, tt.code)
+			}
+			if tt.code != http.StatusOK {
+				return
+			}
+			var status TaskStatus
+			if err := json.Unmarshal(rw.Body.Bytes(), &status); err != nil {
+				t.Fatal(err)
+			}
+			if status.ID != tt.id || status.Status != tt.status || string(status.Result) != tt.result || status.Logs != tt.logs {
+				t.Errorf("handleTaskStatus() = %+v", status)
+			}
+		})
+	}
+}
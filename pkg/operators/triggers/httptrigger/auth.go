@@ -0,0 +1,225 @@
+package httptrigger
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt"
+	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
+	"github.com/refunc/refunc/pkg/messages"
+	"github.com/refunc/refunc/pkg/operators/triggers/commontrigger"
+	"github.com/refunc/refunc/pkg/utils/rfutil"
+)
+
+// well known auth headers
+const (
+	defaultAPIKeyHeader    = "X-API-Key"
+	defaultHMACHeader      = "X-Refunc-Signature"
+	hmacTimestampHeader    = "X-Refunc-Timestamp"
+	defaultHMACSecretKey   = "key"
+	hmacMaxClockSkew       = 5 * time.Minute
+	hmacSignaturePrefixSHA = "sha256="
+)
+
+var (
+	errMissingCredential = errors.New("h: missing credential")
+	errInvalidCredential = errors.New("h: invalid credential")
+	errMissingAuthSecret = errors.New("h: secretName of auth is not configured")
+	errMissingAudience   = errors.New("h: audience of auth is not configured")
+)
+
+// authenticate verifies the caller of a request by trigger's AuthType,
+// returns nil authorizer for public endpoints.
+func (t *httpHandler) authenticate(trigger *rfv1beta3.Trigger, req *http.Request) (*RequestContextAuthorizer, error) {
+	authType, cfg := authConfig(trigger)
+	if cfg == nil {
+		cfg = new(rfv1beta3.HTTPTriggerAuth)
+	}
+
+	switch strings.ToLower(authType) {
+	case "", rfv1beta3.HTTPAuthNone:
+		return nil, nil
+	case rfv1beta3.HTTPAuthJWT:
+		return authenticateJWT(cfg, req)
+	case rfv1beta3.HTTPAuthAPIKey:
+		return t.authenticateAPIKey(cfg, req)
+	case rfv1beta3.HTTPAuthHMAC:
+		return t.authenticateHMAC(cfg, req)
+	}
+	return nil, fmt.Errorf("h: unknown auth type %q", authType)
+}
+
+// authConfig returns the auth of a http or common trigger
+func authConfig(trigger *rfv1beta3.Trigger) (authType string, cfg *rfv1beta3.HTTPTriggerAuth) {
+	switch {
+	case trigger.Spec.Type == commontrigger.Type && trigger.Spec.Common != nil:
+		return trigger.Spec.Common.AuthType, trigger.Spec.Common.Auth
+	case trigger.Spec.HTTP != nil:
+		return trigger.Spec.HTTP.AuthType, trigger.Spec.HTTP.Auth
+	}
+	return "", nil
+}
+
+func authenticateJWT(cfg *rfv1beta3.HTTPTriggerAuth, req *http.Request) (*RequestContextAuthorizer, error) {
+	header := cfg.Header
+	if header == "" {
+		header = "Authorization"
+	}
+	token := req.Header.Get(header)
+	if len(token) > 7 && strings.EqualFold(token[:7], "bearer ") {
+		token = token[7:]
+	}
+	if token == "" {
+		return nil, errMissingCredential
+	}
+
+	claims, err := rfutil.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyClaims(cfg, claims); err != nil {
+		return nil, err
+	}
+	sub, _ := claims["sub"].(string)
+	return &RequestContextAuthorizer{
+		Type:        rfv1beta3.HTTPAuthJWT,
+		PrincipalID: sub,
+		Claims:      claims,
+	}, nil
+}
+
+// verifyClaims checks a token signed by the cluster key is issued for the trigger
+func verifyClaims(cfg *rfv1beta3.HTTPTriggerAuth, claims jwt.MapClaims) error {
+	if cfg.Audience == "" {
+		return errMissingAudience
+	}
+	if !claims.VerifyAudience(cfg.Audience, true) {
+		return errInvalidCredential
+	}
+	if cfg.Issuer != "" && !claims.VerifyIssuer(cfg.Issuer, true) {
+		return errInvalidCredential
+	}
+	if len(cfg.Subjects) == 0 {
+		return nil
+	}
+	sub, _ := claims["sub"].(string)
+	for _, allowed := range cfg.Subjects {
+		if sub == allowed {
+			return nil
+		}
+	}
+	return errInvalidCredential
+}
+
+func (t *httpHandler) authenticateAPIKey(cfg *rfv1beta3.HTTPTriggerAuth, req *http.Request) (*RequestContextAuthorizer, error) {
+	header := cfg.Header
+	if header == "" {
+		header = defaultAPIKeyHeader
+	}
+	apiKey := req.Header.Get(header)
+	if apiKey == "" {
+		return nil, errMissingCredential
+	}
+
+	data, err := t.authSecretData(cfg)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range data {
+		if cfg.SecretKey != "" && name != cfg.SecretKey {
+			continue
+		}
+		if subtle.ConstantTimeCompare(bytes.TrimSpace(value), []byte(apiKey)) == 1 {
+			return &RequestContextAuthorizer{
+				Type:        rfv1beta3.HTTPAuthAPIKey,
+				PrincipalID: name,
+			}, nil
+		}
+	}
+	return nil, errInvalidCredential
+}
+
+// authenticateHMAC verifies signature of a request, which is computed as
+//
+//	hex(hmac_sha256(key, timestamp + "\n" + method + "\n" + requestURI + "\n" + body))
+//
+// where timestamp is the unix seconds in header X-Refunc-Timestamp.
+func (t *httpHandler) authenticateHMAC(cfg *rfv1beta3.HTTPTriggerAuth, req *http.Request) (*RequestContextAuthorizer, error) {
+	header := cfg.Header
+	if header == "" {
+		header = defaultHMACHeader
+	}
+	signature := strings.TrimPrefix(req.Header.Get(header), hmacSignaturePrefixSHA)
+	timestamp := req.Header.Get(hmacTimestampHeader)
+	if signature == "" || timestamp == "" {
+		return nil, errMissingCredential
+	}
+	secs, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, errInvalidCredential
+	}
+	if skew := time.Since(time.Unix(secs, 0)); skew > hmacMaxClockSkew || skew < -hmacMaxClockSkew {
+		return nil, errors.New("h: request signature expired")
+	}
+	sig, err := hex.DecodeString(signature)
+	if err != nil {
+		return nil, errInvalidCredential
+	}
+
+	data, err := t.authSecretData(cfg)
+	if err != nil {
+		return nil, err
+	}
+	secretKey := cfg.SecretKey
+	if secretKey == "" {
+		secretKey = defaultHMACSecretKey
+	}
+	key, ok := data[secretKey]
+	if !ok {
+		return nil, fmt.Errorf("h: key %q not found in secret %q", secretKey, cfg.SecretName)
+	}
+
+	// read body and put it back for later formating
+	body, err := ioutil.ReadAll(io.LimitReader(req.Body, messages.MaxPayloadSize+1))
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	if !hmac.Equal(sig, signHMAC(key, timestamp, req.Method, req.URL.RequestURI(), body)) {
+		return nil, errInvalidCredential
+	}
+	return &RequestContextAuthorizer{
+		Type:        rfv1beta3.HTTPAuthHMAC,
+		PrincipalID: cfg.SecretName,
+	}, nil
+}
+
+func signHMAC(key []byte, timestamp, method, requestURI string, body []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(timestamp + "\n" + method + "\n" + requestURI + "\n")) // nolint:errcheck
+	mac.Write(body)                                                         // nolint:errcheck
+	return mac.Sum(nil)
+}
+
+func (t *httpHandler) authSecretData(cfg *rfv1beta3.HTTPTriggerAuth) (map[string][]byte, error) {
+	if cfg.SecretName == "" {
+		return nil, errMissingAuthSecret
+	}
+	secret, err := t.operator.secretLister.Secrets(t.ns).Get(cfg.SecretName)
+	if err != nil {
+		return nil, err
+	}
+	return secret.Data, nil
+}
@@ -0,0 +1,34 @@
+package httptrigger
+
+import (
+	"testing"
+
+	jwt "github.com/golang-jwt/jwt"
+	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
+)
+
+func Test_verifyClaims(t *testing.T) {
+	cfg := &rfv1beta3.HTTPTriggerAuth{Audience: "ns/hello", Issuer: "refunc", Subjects: []string{"alice"}}
+	tests := []struct {
+		name    string
+		cfg     *rfv1beta3.HTTPTriggerAuth
+		claims  jwt.MapClaims
+		wantErr error
+	}{
+		{"ok", cfg, jwt.MapClaims{"aud": "ns/hello", "iss": "refunc", "sub": "alice"}, nil},
+		{"aud in list", cfg, jwt.MapClaims{"aud": []interface{}{"ns/other", "ns/hello"}, "iss": "refunc", "sub": "alice"}, nil},
+		{"no audience configured", &rfv1beta3.HTTPTriggerAuth{}, jwt.MapClaims{"aud": "ns/hello"}, errMissingAudience},
+		{"missing aud", cfg, jwt.MapClaims{"iss": "refunc", "sub": "alice"}, errInvalidCredential},
+		{"other aud", cfg, jwt.MapClaims{"aud": "ns/other", "iss": "refunc", "sub": "alice"}, errInvalidCredential},
+		{"other issuer", cfg, jwt.MapClaims{"aud": "ns/hello", "iss": "other", "sub": "alice"}, errInvalidCredential},
+		{"other subject", cfg, jwt.MapClaims{"aud": "ns/hello", "iss": "refunc", "sub": "bob"}, errInvalidCredential},
+		{"any subject", &rfv1beta3.HTTPTriggerAuth{Audience: "ns/hello"}, jwt.MapClaims{"aud": "ns/hello", "sub": "bob"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := verifyClaims(tt.cfg, tt.claims); err != tt.wantErr {
+				t.Errorf("verifyClaims() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,9 +1,19 @@
 package httptrigger
 
 import (
+	"encoding/binary"
 	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/klog"
 
 	"github.com/allegro/bigcache"
+	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
+	"github.com/refunc/refunc/pkg/utils"
 )
 
 type httpCache interface {
@@ -37,3 +47,191 @@ func (disabledCache) Reset() error {
 func (disabledCache) Len() int {
 	return 0
 }
+
+const (
+	defaultCacheTTL = time.Minute
+
+	cacheHeader = "X-Refunc-Cache"
+	cacheHit    = "HIT"
+	cacheMiss   = "MISS"
+)
+
+// cachePolicy is the resolved caching config for a single request
+type cachePolicy struct {
+	key string
+	ttl time.Duration
+	// maxAge is the max acceptable age of a cached response, or -1
+	maxAge time.Duration
+
+	lookup bool // serve from cache
+	store  bool // save result to cache
+
+	ignoreCacheControl bool
+}
+
+// newCachePolicy returns nil if the request is not cacheable.
+func newCachePolicy(cfg *rfv1beta3.HTTPTriggerCache, fnHash string, req *http.Request) *cachePolicy {
+	if cfg == nil || (req.Method != http.MethodGet && req.Method != http.MethodHead) {
+		return nil
+	}
+
+	p := &cachePolicy{
+		key:                cacheKey(fnHash, req, cfg.VaryHeaders),
+		ttl:                defaultCacheTTL,
+		maxAge:             -1,
+		lookup:             true,
+		store:              true,
+		ignoreCacheControl: cfg.IgnoreCacheControl,
+	}
+	if cfg.TTL > 0 {
+		p.ttl = time.Duration(cfg.TTL) * time.Second
+	}
+	if cfg.IgnoreCacheControl {
+		return p
+	}
+
+	cc := parseCacheControl(req.Header.Get("Cache-Control"))
+	if _, ok := cc["no-store"]; ok {
+		p.lookup, p.store = false, false
+	}
+	if _, ok := cc["no-cache"]; ok {
+		p.lookup = false
+	}
+	if v, ok := cc["max-age"]; ok {
+		if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+			p.maxAge = time.Duration(secs) * time.Second
+		}
+	}
+	return p
+}
+
+// cacheKey returns the key of a request, the function's hash is part of the key,
+// so that responses of previous versions are never served after an update.
+func cacheKey(fnHash string, req *http.Request, varyHeaders []string) string {
+	parts := [][]byte{
+		[]byte(fnHash),
+		[]byte(req.Method),
+		[]byte(req.URL.Path),
+		// Encode sorts by key
+		[]byte(req.URL.Query().Encode()),
+	}
+	headers := make([]string, 0, len(varyHeaders))
+	for _, h := range varyHeaders {
+		h = http.CanonicalHeaderKey(h)
+		headers = append(headers, h+"="+strings.Join(req.Header.Values(h), ","))
+	}
+	sort.Strings(headers)
+	for _, h := range headers {
+		parts = append(parts, []byte{'\n'}, []byte(h))
+	}
+	return utils.GenID(parts...)
+}
+
+// parseCacheControl parses directives of a Cache-Control header
+func parseCacheControl(header string) map[string]string {
+	directives := make(map[string]string)
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		name := strings.ToLower(strings.TrimSpace(kv[0]))
+		if len(kv) == 2 {
+			directives[name] = strings.Trim(strings.TrimSpace(kv[1]), `"`)
+		} else {
+			directives[name] = ""
+		}
+	}
+	return directives
+}
+
+// responseTTL returns how long the result could be cached,
+// zero means the result must not be cached.
+func (p *cachePolicy) responseTTL(rsp ResponsePayload) time.Duration {
+	if rsp.StatusCode != http.StatusOK {
+		return 0
+	}
+	if p.ignoreCacheControl {
+		return p.ttl
+	}
+	if v := rsp.header().Get("Cache-Control"); v != "" {
+		cc := parseCacheControl(v)
+		for _, d := range []string{"no-store", "no-cache", "private"} {
+			if _, ok := cc[d]; ok {
+				return 0
+			}
+		}
+		for _, d := range []string{"s-maxage", "max-age"} {
+			if v, ok := cc[d]; ok {
+				if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+					return time.Duration(secs) * time.Second
+				}
+			}
+		}
+	}
+	return p.ttl
+}
+
+// cache entry layout: | expires(8) | created(8) | result |
+const cacheEntryHeaderSize = 16
+
+func encodeCacheEntry(created time.Time, ttl time.Duration, result []byte) []byte {
+	entry := make([]byte, cacheEntryHeaderSize+len(result))
+	binary.BigEndian.PutUint64(entry[0:8], uint64(created.Add(ttl).UnixNano()))
+	binary.BigEndian.PutUint64(entry[8:16], uint64(created.UnixNano()))
+	copy(entry[cacheEntryHeaderSize:], result)
+	return entry
+}
+
+func decodeCacheEntry(entry []byte) (expires, created time.Time, result []byte, err error) {
+	if len(entry) < cacheEntryHeaderSize {
+		err = errors.New("router: invalid cache entry")
+		return
+	}
+	expires = time.Unix(0, int64(binary.BigEndian.Uint64(entry[0:8])))
+	created = time.Unix(0, int64(binary.BigEndian.Uint64(entry[8:16])))
+	result = entry[cacheEntryHeaderSize:]
+	return
+}
+
+// loadCachedResult returns a cached result and its age
+func (r *Operator) loadCachedResult(p *cachePolicy) ([]byte, time.Duration, bool) {
+	if p == nil || !p.lookup {
+		return nil, 0, false
+	}
+	entry, err := r.http.cache.Get(p.key)
+	if err != nil {
+		return nil, 0, false
+	}
+	expires, created, result, err := decodeCacheEntry(entry)
+	if err != nil {
+		return nil, 0, false
+	}
+	now := time.Now()
+	age := now.Sub(created)
+	if now.After(expires) || (p.maxAge >= 0 && age > p.maxAge) {
+		return nil, 0, false
+	}
+	return result, age, true
+}
+
+// storeResult saves the result of a function to cache
+func (r *Operator) storeResult(p *cachePolicy, format string, result []byte) {
+	if p == nil || !p.store {
+		return
+	}
+	rsp, err := formatResponsePayload(format, result)
+	if err != nil {
+		return
+	}
+	ttl := p.responseTTL(rsp)
+	if ttl <= 0 {
+		return
+	}
+	if err := r.http.cache.Set(p.key, encodeCacheEntry(time.Now(), ttl, result)); err != nil {
+		klog.V(3).Infof("(h) failed to set cache %s, %v", p.key, err)
+		return
+	}
+	klog.V(3).Infof("(h) set cache %s, ttl %v", p.key, ttl)
+}
@@ -0,0 +1,137 @@
+package httptrigger
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
+)
+
+func Test_cacheKey(t *testing.T) {
+	vary := []string{"accept-language"}
+	key := func(fnHash, method, target string, headers map[string]string) string {
+		req := httptest.NewRequest(method, target, nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		return cacheKey(fnHash, req, vary)
+	}
+
+	base := key("h1", "GET", "/ns/fn?a=1&b=2", map[string]string{"Accept-Language": "en"})
+
+	tests := []struct {
+		name string
+		key  string
+		same bool
+	}{
+		{"query order", key("h1", "GET", "/ns/fn?b=2&a=1", map[string]string{"Accept-Language": "en"}), true},
+		{"other header", key("h1", "GET", "/ns/fn?a=1&b=2", map[string]string{"Accept-Language": "en", "X-Other": "1"}), true},
+		{"func updated", key("h2", "GET", "/ns/fn?a=1&b=2", map[string]string{"Accept-Language": "en"}), false},
+		{"method", key("h1", "HEAD", "/ns/fn?a=1&b=2", map[string]string{"Accept-Language": "en"}), false},
+		{"path", key("h1", "GET", "/ns/fn/x?a=1&b=2", map[string]string{"Accept-Language": "en"}), false},
+		{"query", key("h1", "GET", "/ns/fn?a=1&b=3", map[string]string{"Accept-Language": "en"}), false},
+		{"vary header", key("h1", "GET", "/ns/fn?a=1&b=2", map[string]string{"Accept-Language": "fr"}), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if (tt.key == base) != tt.same {
+				t.Errorf("cacheKey() same = %v, want %v", tt.key == base, tt.same)
+			}
+		})
+	}
+}
+
+func Test_resultKeyBase(t *testing.T) {
+	fndef := &rfv1beta3.Funcdef{Spec: rfv1beta3.FuncdefSpec{Hash: "hash"}}
+	newTrigger := func(name string) *rfv1beta3.Trigger {
+		return &rfv1beta3.Trigger{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name}}
+	}
+	alice := &RequestContextAuthorizer{Type: "jwt", PrincipalID: "alice"}
+
+	base := resultKeyBase(fndef, newTrigger("public"), nil)
+	if resultKeyBase(fndef, newTrigger("public"), nil) != base {
+		t.Error("keys of the same trigger differ")
+	}
+	if resultKeyBase(fndef, newTrigger("internal"), nil) == base {
+		t.Error("triggers of the same funcdef share keys")
+	}
+	if resultKeyBase(fndef, newTrigger("public"), alice) == base {
+		t.Error("callers share keys")
+	}
+}
+
+func Test_newCachePolicy(t *testing.T) {
+	cfg := &rfv1beta3.HTTPTriggerCache{TTL: 10}
+
+	tests := []struct {
+		name         string
+		cfg          *rfv1beta3.HTTPTriggerCache
+		method       string
+		cacheControl string
+		wantNil      bool
+		lookup       bool
+		store        bool
+		maxAge       time.Duration
+	}{
+		{"disabled", nil, "GET", "", true, false, false, 0},
+		{"post", cfg, "POST", "", true, false, false, 0},
+		{"get", cfg, "GET", "", false, true, true, -1},
+		{"no-cache", cfg, "GET", "no-cache", false, false, true, -1},
+		{"no-store", cfg, "GET", "no-store", false, false, false, -1},
+		{"max-age", cfg, "HEAD", "max-age=5", false, true, true, 5 * time.Second},
+		{"ignored", &rfv1beta3.HTTPTriggerCache{IgnoreCacheControl: true}, "GET", "no-store", false, true, true, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/ns/fn", nil)
+			req.Header.Set("Cache-Control", tt.cacheControl)
+			p := newCachePolicy(tt.cfg, "hash", req)
+			if (p == nil) != tt.wantNil {
+				t.Fatalf("newCachePolicy() = %v, wantNil %v", p, tt.wantNil)
+			}
+			if p == nil {
+				return
+			}
+			if p.lookup != tt.lookup || p.store != tt.store || p.maxAge != tt.maxAge {
+				t.Errorf("newCachePolicy() = %+v, want lookup %v store %v maxAge %v", p, tt.lookup, tt.store, tt.maxAge)
+			}
+		})
+	}
+}
+
+func Test_cachePolicy_responseTTL(t *testing.T) {
+	p := &cachePolicy{ttl: time.Minute}
+
+	tests := []struct {
+		name string
+		rsp  ResponsePayload
+		want time.Duration
+	}{
+		{"default", ResponsePayload{StatusCode: 200}, time.Minute},
+		{"error", ResponsePayload{StatusCode: 500}, 0},
+		{"max-age", ResponsePayload{StatusCode: 200, Headers: map[string]string{"cache-control": "public, max-age=30"}}, 30 * time.Second},
+		{"s-maxage", ResponsePayload{StatusCode: 200, Headers: map[string]string{"Cache-Control": "max-age=30, s-maxage=90"}}, 90 * time.Second},
+		{"private", ResponsePayload{StatusCode: 200, Headers: map[string]string{"Cache-Control": "private, max-age=30"}}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.responseTTL(tt.rsp); got != tt.want {
+				t.Errorf("responseTTL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_cacheEntry(t *testing.T) {
+	now := time.Now()
+	expires, created, result, err := decodeCacheEntry(encodeCacheEntry(now, time.Minute, []byte("ok")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created.Equal(now) || !expires.Equal(now.Add(time.Minute)) || string(result) != "ok" {
+		t.Errorf("decodeCacheEntry() = %v, %v, %q", expires, created, result)
+	}
+}
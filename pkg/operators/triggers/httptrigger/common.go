@@ -0,0 +1,106 @@
+package httptrigger
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"k8s.io/klog"
+
+	"github.com/gorilla/mux"
+	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
+	"github.com/refunc/refunc/pkg/client"
+	"github.com/refunc/refunc/pkg/messages"
+	"github.com/refunc/refunc/pkg/operators/triggers/commontrigger"
+)
+
+// setupCommonTriggers routes POST /_triggers/ns/name to common triggers,
+// the body is the args of invocation. Only triggers with an AuthType are served,
+// requests are authenticated and rate limited as http triggers.
+func (r *Operator) setupCommonTriggers(router *mux.Router) {
+	router.Path("/_triggers/{ns}/{name}").Methods(http.MethodPost).HandlerFunc(r.handleCommonTrigger)
+}
+
+func (r *Operator) handleCommonTrigger(rw http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	ns, name := vars["ns"], vars["name"]
+	if r.Namespace != "" && ns != r.Namespace {
+		writeHTTPError(rw, http.StatusNotFound, "trigger not found")
+		return
+	}
+	trigger, err := r.TriggerLister.Triggers(ns).Get(name)
+	if err != nil || trigger.Spec.Type != commontrigger.Type || trigger.Spec.Common == nil {
+		writeHTTPError(rw, http.StatusNotFound, "trigger not found")
+		return
+	}
+	// not exposed to the gateway without auth
+	if authType, _ := authConfig(trigger); authType == "" || strings.EqualFold(authType, rfv1beta3.HTTPAuthNone) {
+		writeHTTPError(rw, http.StatusNotFound, "trigger not found")
+		return
+	}
+
+	h := &httpHandler{fndKey: fndKey(trigger), ns: ns, name: name, operator: r}
+	authorizer, err := h.authenticate(trigger, req)
+	if err != nil {
+		klog.V(3).Infof("(h) %s/%s unauthorized, %v", ns, name, err)
+		writeHTTPError(rw, http.StatusUnauthorized, err.Error())
+		return
+	}
+	release, retryAfter, ok := r.limiters.admit(trigger, req, authorizer)
+	if !ok {
+		klog.V(3).Infof("(h) %s/%s throttled, retry after %v", ns, name, retryAfter)
+		writeTooManyRequests(rw, retryAfter)
+		return
+	}
+	defer release()
+	natsConn := client.GetNatsConn(r.ctx)
+	if natsConn == nil {
+		writeHTTPError(rw, http.StatusServiceUnavailable, "nats is not connected")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(rw, req.Body, messages.MaxPayloadSize))
+	if err != nil {
+		writeHTTPError(rw, http.StatusRequestEntityTooLarge, err.Error())
+		return
+	}
+	request := &messages.InvokeRequest{RequestID: getRequestID(req)}
+	if len(body) > 0 {
+		if !json.Valid(body) {
+			writeHTTPError(rw, http.StatusBadRequest, "body must be a valid json")
+			return
+		}
+		request.Args = json.RawMessage(body)
+	}
+
+	timeout := messages.DefaultJobTimeout
+	if fndef, err := r.ResolveFuncdef(trigger); err == nil && fndef.Spec.Runtime != nil && fndef.Spec.Runtime.Timeout > 0 {
+		timeout = time.Second*time.Duration(fndef.Spec.Runtime.Timeout) + 10*time.Second
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	defer cancel()
+
+	msg, err := natsConn.RequestWithContext(ctx, trigger.CommonEndpoint(), messages.MustFromObject(request))
+	if err != nil {
+		klog.Errorf("(httptrigger) %s/%s failed to invoke, %v", ns, name, err)
+		writeHTTPError(rw, http.StatusGatewayTimeout, err.Error())
+		return
+	}
+	var rsp messages.InvokeResponse
+	if err := json.Unmarshal(msg.Data, &rsp); err != nil {
+		writeHTTPError(rw, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	rw.Header().Set("Content-Type", jsonCT)
+	if rsp.Error != nil {
+		rw.WriteHeader(http.StatusInternalServerError)
+		rw.Write(messages.GetErrActionBytes(*rsp.Error)) // nolint:errcheck
+		return
+	}
+	rw.WriteHeader(http.StatusOK)
+	rw.Write(rsp.Payload) // nolint:errcheck
+}
@@ -0,0 +1,82 @@
+package httptrigger
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/gorilla/mux"
+	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
+	rflistersv1 "github.com/refunc/refunc/pkg/generated/listers/refunc/v1beta3"
+	"github.com/refunc/refunc/pkg/operators"
+	"github.com/refunc/refunc/pkg/operators/triggers/commontrigger"
+)
+
+func TestOperator_handleCommonTrigger(t *testing.T) {
+	newTrigger := func(name string, common *rfv1beta3.CommonTrigger) *rfv1beta3.Trigger {
+		return &rfv1beta3.Trigger{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name},
+			Spec: rfv1beta3.TriggerSpec{
+				FuncName:      "fn",
+				Type:          commontrigger.Type,
+				TriggerConfig: rfv1beta3.TriggerConfig{Common: common},
+			},
+		}
+	}
+	triggers := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	triggers.Add(newTrigger("open", &rfv1beta3.CommonTrigger{})) // nolint:errcheck
+	triggers.Add(newTrigger("keyed", &rfv1beta3.CommonTrigger{   // nolint:errcheck
+		AuthType:  rfv1beta3.HTTPAuthAPIKey,
+		Auth:      &rfv1beta3.HTTPTriggerAuth{SecretName: "keys"},
+		RateLimit: &rfv1beta3.HTTPTriggerRateLimit{RPS: 1},
+	}))
+	secrets := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	secrets.Add(&corev1.Secret{ // nolint:errcheck
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "keys"},
+		Data:       map[string][]byte{"alice": []byte("secret")},
+	})
+
+	r := &Operator{
+		BaseOperator: &operators.BaseOperator{
+			TriggerLister: rflistersv1.NewTriggerLister(triggers),
+			FuncdefLister: rflistersv1.NewFuncdefLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})),
+		},
+		ctx:          context.Background(),
+		secretLister: corelisters.NewSecretLister(secrets),
+	}
+	router := mux.NewRouter()
+	r.setupCommonTriggers(router)
+
+	tests := []struct {
+		name   string
+		path   string
+		apiKey string
+		code   int
+	}{
+		{"not exposed without auth", "/_triggers/ns/open", "", http.StatusNotFound},
+		{"missing key", "/_triggers/ns/keyed", "", http.StatusUnauthorized},
+		{"invalid key", "/_triggers/ns/keyed", "guess", http.StatusUnauthorized},
+		// admitted, nats is not connected in tests
+		{"valid key", "/_triggers/ns/keyed", "secret", http.StatusServiceUnavailable},
+		{"throttled", "/_triggers/ns/keyed", "secret", http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, nil)
+			if tt.apiKey != "" {
+				req.Header.Set(defaultAPIKeyHeader, tt.apiKey)
+			}
+			rw := httptest.NewRecorder()
+			router.ServeHTTP(rw, req)
+			if rw.Code != tt.code {
+				t.Errorf("code = %d, want %d, body %q", rw.Code, tt.code, rw.Body.String())
+			}
+		})
+	}
+}
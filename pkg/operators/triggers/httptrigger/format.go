@@ -8,12 +8,14 @@ import (
 	"strings"
 
 	"github.com/gabriel-vasile/mimetype"
+	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
 )
 
 // https://docs.aws.amazon.com/apigateway/latest/developerguide/http-api-develop-integrations-lambda.html#http-api-develop-integrations-lambda.proxy-format
 
 type RequestPayload struct {
 	Version               string            `json:"version"`
+	RouteKey              string            `json:"routeKey"`
 	RawPath               string            `json:"rawPath"`
 	RawQueryString        string            `json:"rawQueryString"`
 	Cookies               []string          `json:"cookies"`
@@ -26,9 +28,17 @@ type RequestPayload struct {
 }
 
 type RequestContext struct {
-	DomainName string             `json:"domainName"`
-	HTTP       RequestContextHTTP `json:"http"`
-	RequestID  string             `json:"requestId"`
+	DomainName string                    `json:"domainName"`
+	HTTP       RequestContextHTTP        `json:"http"`
+	RequestID  string                    `json:"requestId"`
+	Authorizer *RequestContextAuthorizer `json:"authorizer,omitempty"`
+}
+
+// RequestContextAuthorizer is the identity of a caller verified by trigger's AuthType
+type RequestContextAuthorizer struct {
+	Type        string                 `json:"type"`
+	PrincipalID string                 `json:"principalId,omitempty"`
+	Claims      map[string]interface{} `json:"claims,omitempty"`
 }
 
 type RequestContextHTTP struct {
@@ -40,11 +50,13 @@ type RequestContextHTTP struct {
 }
 
 type ResponsePayload struct {
-	StatusCode      int               `json:"statusCode"`
-	Headers         map[string]string `json:"headers"`
-	Body            string            `json:"body"`
-	Cookies         []string          `json:"cookies"`
-	IsBase64Encoded bool              `json:"isBase64Encoded"`
+	StatusCode int               `json:"statusCode"`
+	Headers    map[string]string `json:"headers"`
+	// MultiValueHeaders is used by 1.0 and alb payload formats
+	MultiValueHeaders map[string][]string `json:"multiValueHeaders,omitempty"`
+	Body              string              `json:"body"`
+	Cookies           []string            `json:"cookies"`
+	IsBase64Encoded   bool                `json:"isBase64Encoded"`
 }
 
 func formatRequestPayload(req *http.Request) (RequestPayload, error) {
@@ -68,6 +80,7 @@ func formatRequestPayload(req *http.Request) (RequestPayload, error) {
 
 	payload := RequestPayload{
 		Version:               "2.0",
+		RouteKey:              defaultRouteKey,
 		RawPath:               req.URL.EscapedPath(),
 		RawQueryString:        req.URL.RawQuery,
 		Cookies:               cookies,
@@ -112,8 +125,22 @@ func isBinary(bts []byte) bool {
 	return isBinary
 }
 
-func formatResponsePayload(bts []byte) (ResponsePayload, error) {
+func formatResponsePayload(format string, bts []byte) (ResponsePayload, error) {
 	payload := ResponsePayload{StatusCode: 0}
+	switch format {
+	case rfv1beta3.HTTPPayloadFormatRaw:
+		payload.StatusCode = 200
+		payload.Body = string(bts)
+		return payload, nil
+
+	case rfv1beta3.HTTPPayloadFormatV1, rfv1beta3.HTTPPayloadFormatALB:
+		// status code is required by REST API and ALB
+		if err := json.Unmarshal(bts, &payload); err != nil || payload.StatusCode == 0 {
+			return payload, errMalformedResponse
+		}
+		return payload, nil
+	}
+
 	// any response or json response
 	if err := json.Unmarshal(bts, &payload); err != nil || payload.StatusCode == 0 {
 		payload.StatusCode = 200
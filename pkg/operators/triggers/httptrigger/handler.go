@@ -2,11 +2,11 @@ package httptrigger
 
 import (
 	"context"
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,8 +15,10 @@ import (
 	"github.com/gabriel-vasile/mimetype"
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
+	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
 	"github.com/refunc/refunc/pkg/client"
 	"github.com/refunc/refunc/pkg/messages"
+	"github.com/refunc/refunc/pkg/operators"
 	"github.com/refunc/refunc/pkg/utils"
 	"github.com/refunc/refunc/pkg/utils/k8sutil"
 )
@@ -42,8 +44,12 @@ func (t *httpHandler) setupHTTPEndpoints(router *mux.Router) {
 	base := "/" + t.fndKey
 
 	// subrouter prefixed with /ns/refunc-name
-	sr := router.PathPrefix(base).Subrouter()
+	t.setupRoutes(router.PathPrefix(base).Subrouter())
+}
 
+// setupRoutes registers routes of the trigger to sr, the comments of routes
+// show paths of the default mount /ns/refunc-name.
+func (t *httpHandler) setupRoutes(sr *mux.Router) {
 	const (
 		streamingOn  = true
 		streamingOff = false
@@ -55,15 +61,50 @@ func (t *httpHandler) setupHTTPEndpoints(router *mux.Router) {
 		return
 	}
 
-	// Methods /ns/refunc-name
-	sr.HandleFunc("", t.taskCreationHandler(streamingOff))
-	// Methods /ns/refunc-name/*
-	sr.PathPrefix("/").HandlerFunc(t.taskCreationHandler(streamingOff))
-
+	// GET /ns/refunc-name/_tasks/{id}
+	// query status of an async invocation
+	sr.HandleFunc("/_tasks/{id}", t.handleTaskStatus).Methods(http.MethodGet)
 	// GET /ns/refunc-name/_meta
 	// query metadata of the refunc
 	sr.HandleFunc("/_meta", t.handleMeta).Methods(http.MethodGet)
 
+	if trigger.Spec.HTTP != nil && len(trigger.Spec.HTTP.Routes) > 0 {
+		for _, route := range trigger.Spec.HTTP.Routes {
+			method, tmpl, err := parseRoute(route)
+			if err != nil {
+				klog.Warningf("(h) %s skip route %q, %v", t.fndKey, route, err)
+				continue
+			}
+			routeKey := method + " " + tmpl
+			handle := func(prefix string, streaming bool) {
+				paths := []string{prefix + tmpl}
+				if tmpl == "/" {
+					paths = append(paths, prefix)
+				}
+				for _, p := range paths {
+					r := sr.HandleFunc(p, t.taskCreationHandler(streaming, routeKey))
+					if method != anyMethod {
+						// allows CORS preflight
+						r.Methods(method, http.MethodOptions)
+					}
+				}
+			}
+			// Methods /ns/refunc-name/_stream/<route>
+			handle("/_stream", streamingOn)
+			// Methods /ns/refunc-name/<route>
+			handle("", streamingOff)
+		}
+	} else {
+		// Methods /ns/refunc-name/_stream[/*]
+		// streams logs and messages of the function as they arrive
+		sr.HandleFunc("/_stream", t.taskCreationHandler(streamingOn, defaultRouteKey))
+		sr.PathPrefix("/_stream/").HandlerFunc(t.taskCreationHandler(streamingOn, defaultRouteKey))
+		// Methods /ns/refunc-name
+		sr.HandleFunc("", t.taskCreationHandler(streamingOff, defaultRouteKey))
+		// Methods /ns/refunc-name/*
+		sr.PathPrefix("/").HandlerFunc(t.taskCreationHandler(streamingOff, defaultRouteKey))
+	}
+
 	// setup http.Handler configs
 	if trigger.Spec.HTTP == nil {
 		if len(t.operator.corsOpts) > 0 {
@@ -100,7 +141,7 @@ func (t *httpHandler) setupHTTPEndpoints(router *mux.Router) {
 	}
 }
 
-func (t *httpHandler) taskCreationHandler(streaming bool) func(http.ResponseWriter, *http.Request) {
+func (t *httpHandler) taskCreationHandler(streaming bool, routeKey string) func(http.ResponseWriter, *http.Request) {
 	return func(rw http.ResponseWriter, req *http.Request) {
 		defer func() {
 			if re := recover(); re != nil {
@@ -115,7 +156,8 @@ func (t *httpHandler) taskCreationHandler(streaming bool) func(http.ResponseWrit
 			return
 		}
 
-		fndef, err := t.operator.ResolveFuncdef(trigger)
+		// the version of function serving this request
+		fndef, err := t.operator.RouteFuncdef(trigger)
 		if err != nil {
 			if k8sutil.IsResourceNotFoundError(err) {
 				writeHTTPError(rw, http.StatusNotFound, err.Error())
@@ -135,6 +177,50 @@ func (t *httpHandler) taskCreationHandler(streaming bool) func(http.ResponseWrit
 			return
 		}
 
+		authorizer, err := t.authenticate(trigger, req)
+		if err != nil {
+			klog.V(3).Infof("(h) %s unauthorized, %v", t.fndKey, err)
+			writeHTTPError(rw, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		release, retryAfter, ok := t.operator.limiters.admit(trigger, req, authorizer)
+		if !ok {
+			klog.V(3).Infof("(h) %s throttled, retry after %v", t.fndKey, retryAfter)
+			writeTooManyRequests(rw, retryAfter)
+			return
+		}
+		defer func() { release() }()
+
+		// serve from cache
+		var (
+			cacheCfg *rfv1beta3.HTTPTriggerCache
+			collapse bool
+		)
+		if trigger.Spec.HTTP != nil {
+			cacheCfg = trigger.Spec.HTTP.Cache
+			collapse = trigger.Spec.HTTP.CollapseRequests
+		}
+		async := !streaming && isAsyncRequest(req)
+		if streaming || async {
+			// streamed or async responses are never cached
+			cacheCfg = nil
+		}
+		keyBase := resultKeyBase(fndef, trigger, authorizer)
+		cp := newCachePolicy(cacheCfg, keyBase, req)
+		if bts, age, ok := t.operator.loadCachedResult(cp); ok {
+			klog.V(3).Infof("(h) %s cache hit", t.fndKey)
+			rw.Header().Set(cacheHeader, cacheHit)
+			rw.Header().Set("Age", strconv.Itoa(int(age.Seconds())))
+			if _, err := t.writeResult(rw, payloadFormat(trigger), bts, false); err != nil {
+				klog.Errorf("(h) %s failed to write cached result, %v", t.fndKey, err)
+			}
+			return
+		}
+		if cp != nil {
+			rw.Header().Set(cacheHeader, cacheMiss)
+		}
+
 		// parse http.request to event
 		event, err := formatRequestPayload(req)
 		if err != nil {
@@ -142,19 +228,50 @@ func (t *httpHandler) taskCreationHandler(streaming bool) func(http.ResponseWrit
 			return
 		}
 
+		event.Context.Authorizer = authorizer
+		event.RouteKey = routeKey
+		for k, v := range mux.Vars(req) {
+			event.PathParameters[k] = v
+		}
+
 		// create request
 		id := path.Join(t.fndKey, event.Context.RequestID)
 
 		request := &messages.InvokeRequest{
-			Args:      messages.MustFromObject(event),
 			RequestID: event.Context.RequestID,
 			Options: map[string]interface{}{
 				"method": strings.ToLower(req.Method),
 			},
 		}
+		request.Args, err = encodeRequestPayload(trigger, event, req, request.Options)
+		if err != nil {
+			writeHTTPError(rw, http.StatusBadRequest, err.Error())
+			return
+		}
+		if streaming {
+			request.Options["logging"] = true
+		}
+
+		if async {
+			// the in-flight slot is held by the async task until it finishes
+			t.handleAsyncInvoke(rw, req, fndef.DeepCopy(), trigger.DeepCopy(), request, authorizer, release)
+			release = func() {}
+			return
+		}
+
+		// identical requests share the same task when collapsing is enabled,
+		// streamed requests are not collapsed as their tasks forward logs.
+		taskID := request.RequestID
+		if collapse && !streaming {
+			var varyHeaders []string
+			if cacheCfg != nil {
+				varyHeaders = cacheCfg.VaryHeaders
+			}
+			taskID = collapseKey(keyBase, req, varyHeaders, event.Body)
+		}
 
 		// get TaskResolver
-		taskr, err := t.ensureTask(fndef.DeepCopy(), trigger.DeepCopy(), request)
+		taskr, err := t.ensureTask(taskID, fndef.DeepCopy(), trigger.DeepCopy(), request, cp)
 		if err != nil {
 			klog.Errorf("(h) %s failed to start task, %v", id, err)
 			writeHTTPError(rw, http.StatusInternalServerError, err.Error())
@@ -162,8 +279,23 @@ func (t *httpHandler) taskCreationHandler(streaming bool) func(http.ResponseWrit
 		}
 
 		ctx := req.Context()
-		t.taskPoller(ctx, rw, taskr, blockTickerCh)()
+		if streaming {
+			t.streamTask(ctx, rw, req, taskr)
+			return
+		}
+		t.taskPoller(ctx, rw, payloadFormat(trigger), taskr, blockTickerCh)()
+	}
+}
+
+// resultKeyBase returns the base of keys of cached results and collapsed tasks,
+// results are never shared between triggers or callers, triggers of the same
+// funcdef differ in hosts, payload formats, caches and auths.
+func resultKeyBase(fndef *rfv1beta3.Funcdef, trigger *rfv1beta3.Trigger, authorizer *RequestContextAuthorizer) string {
+	keyBase := fndef.Spec.Hash + "/" + k8sKey(trigger)
+	if authorizer != nil {
+		keyBase += "/" + authorizer.Type + "/" + authorizer.PrincipalID
 	}
+	return keyBase
 }
 
 func (t *httpHandler) handleMeta(rw http.ResponseWriter, req *http.Request) {
@@ -174,10 +306,32 @@ func (t *httpHandler) handleMeta(rw http.ResponseWriter, req *http.Request) {
 		}
 	}()
 
-	// TODO (bin): maybe using annotations
-	// serve embeded meta
+	trigger, err := t.operator.TriggerLister.Triggers(t.ns).Get(t.name)
+	if err != nil {
+		writeHTTPError(rw, http.StatusBadRequest, err.Error())
+		return
+	}
+	if _, err := t.authenticate(trigger, req); err != nil {
+		writeHTTPError(rw, http.StatusUnauthorized, err.Error())
+		return
+	}
+	fndef, err := t.operator.ResolveFuncdef(trigger)
+	if err != nil {
+		if k8sutil.IsResourceNotFoundError(err) {
+			writeHTTPError(rw, http.StatusNotFound, err.Error())
+		} else {
+			writeHTTPError(rw, http.StatusBadRequest, err.Error())
+		}
+		return
+	}
+	meta, err := operators.NewFuncMeta(fndef, trigger, t.operator.fniLister)
+	if err != nil {
+		writeHTTPError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+
 	rw.Header().Set("Content-Type", jsonCT)
-	rw.Write(append([]byte{'{', '}'}, messages.TokenCRLF...)) // nolint:errcheck
+	rw.Write(append(messages.MustFromObject(meta), messages.TokenCRLF...)) // nolint:errcheck
 }
 
 func (t *httpHandler) flushWriter(rw http.ResponseWriter, idH string) func([]byte) bool {
@@ -195,6 +349,7 @@ func (t *httpHandler) flushWriter(rw http.ResponseWriter, idH string) func([]byt
 func (t *httpHandler) taskPoller(
 	ctx context.Context,
 	rw http.ResponseWriter,
+	format string,
 	taskr client.TaskResolver,
 	tickerC <-chan time.Time,
 ) func() bool {
@@ -216,7 +371,7 @@ func (t *httpHandler) taskPoller(
 			if err != nil {
 				bts = messages.GetErrActionBytes(err)
 			}
-			if _, err := t.writeResult(rw, bts, !(err == nil)); err != nil {
+			if _, err := t.writeResult(rw, format, bts, !(err == nil)); err != nil {
 				klog.Errorf("(h) %s failed to write result, %v", taskr.ID(), err)
 			}
 		}
@@ -224,7 +379,7 @@ func (t *httpHandler) taskPoller(
 	}
 }
 
-func (t *httpHandler) writeResult(rw http.ResponseWriter, bts []byte, isErr bool) (n int, err error) {
+func (t *httpHandler) writeResult(rw http.ResponseWriter, format string, bts []byte, isErr bool) (n int, err error) {
 	if isErr {
 		var msg messages.Action
 		err = json.Unmarshal(bts, &msg)
@@ -238,28 +393,21 @@ func (t *httpHandler) writeResult(rw http.ResponseWriter, bts []byte, isErr bool
 	}
 
 	// https://docs.aws.amazon.com/lambda/latest/dg/urls-invocation.html#urls-payloads
-	rsp, err := formatResponsePayload(bts)
+	rsp, err := formatResponsePayload(format, bts)
 	if err != nil {
-		rw.WriteHeader(http.StatusInternalServerError)
+		rw.WriteHeader(http.StatusBadGateway)
 		return rw.Write(append([]byte(err.Error()), messages.TokenCRLF...))
 	}
 
-	body := []byte(rsp.Body)
-	if rsp.IsBase64Encoded {
-		body, err = base64.StdEncoding.DecodeString(rsp.Body)
-		if err != nil {
-			rw.WriteHeader(http.StatusInternalServerError)
-			return rw.Write([]byte(err.Error()))
-		}
+	body, err := rsp.decodeBody()
+	if err != nil {
+		rw.WriteHeader(http.StatusInternalServerError)
+		return rw.Write([]byte(err.Error()))
 	}
 
 	rw.Header().Set("Content-Type", mimetype.Detect(body).String())
-	for k, v := range rsp.Headers {
-		rw.Header().Set(k, v)
-	}
-
-	for _, cookie := range rsp.Cookies {
-		rw.Header().Add("Set-Cookie", cookie)
+	for k, vs := range rsp.header() {
+		rw.Header()[k] = vs
 	}
 
 	rw.WriteHeader(rsp.StatusCode)
@@ -0,0 +1,128 @@
+package httptrigger
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog"
+
+	"github.com/gorilla/mux"
+	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
+)
+
+// reason of events for triggers that cannot be mounted
+const reasonHostConflict = "HostConflict"
+
+// hostMount is a trigger exposed at custom host and path prefix
+type hostMount struct {
+	host   string
+	prefix string
+
+	handler *httpHandler
+}
+
+func (m *hostMount) key() string {
+	return m.host + m.prefix
+}
+
+// subrouter returns router for requests of the mount
+func (m *hostMount) subrouter(router *mux.Router) *mux.Router {
+	route := router.Host(m.host)
+	if m.prefix != "/" {
+		route = route.PathPrefix(m.prefix).MatcherFunc(m.matchPath)
+	}
+	return route.Subrouter()
+}
+
+// matchPath checks the path is the prefix or under it, PathPrefix alone
+// matches partial segments, /api would serve /apix
+func (m *hostMount) matchPath(req *http.Request, _ *mux.RouteMatch) bool {
+	path := req.URL.Path
+	return path == m.prefix || strings.HasPrefix(path, m.prefix+"/")
+}
+
+func normalizePathPrefix(prefix string) string {
+	return "/" + strings.Trim(prefix, "/")
+}
+
+// resolveHostMounts returns mounts of given handlers in order of registration,
+// if more than one trigger claims the same host and prefix, the oldest one wins
+// and the others are reported by events once the conflict appears.
+func (r *Operator) resolveHostMounts(handlers []*httpHandler) []*hostMount {
+	type candidate struct {
+		handler *httpHandler
+		trigger *rfv1beta3.Trigger
+	}
+	var candidates []candidate
+	for _, h := range handlers {
+		trigger, err := r.TriggerLister.Triggers(h.ns).Get(h.name)
+		if err != nil || trigger.Spec.HTTP == nil || len(trigger.Spec.HTTP.Hosts) == 0 {
+			continue
+		}
+		candidates = append(candidates, candidate{h, trigger})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		ti, tj := candidates[i].trigger, candidates[j].trigger
+		if !ti.CreationTimestamp.Equal(&tj.CreationTimestamp) {
+			return ti.CreationTimestamp.Before(&tj.CreationTimestamp)
+		}
+		return k8sKey(ti) < k8sKey(tj)
+	})
+
+	owners := make(map[string]*rfv1beta3.Trigger)
+	conflicts := make(map[string]bool)
+	var mounts []*hostMount
+	for _, c := range candidates {
+		prefix := normalizePathPrefix(c.trigger.Spec.HTTP.PathPrefix)
+		for _, host := range c.trigger.Spec.HTTP.Hosts {
+			m := &hostMount{host: strings.ToLower(host), prefix: prefix, handler: c.handler}
+			if owner, ok := owners[m.key()]; ok {
+				if owner != c.trigger {
+					conflicts[r.reportHostConflict(c.trigger, owner, m)] = true
+				}
+				continue
+			}
+			owners[m.key()] = c.trigger
+			mounts = append(mounts, m)
+		}
+	}
+	// forget resolved conflicts, so that they are reported again if reappear
+	r.hostConflicts.Lock()
+	r.hostConflicts.reported = conflicts
+	r.hostConflicts.Unlock()
+
+	// longer prefixes first, as mux picks the first matched route
+	sort.SliceStable(mounts, func(i, j int) bool {
+		if mounts[i].host != mounts[j].host {
+			return mounts[i].host < mounts[j].host
+		}
+		return len(mounts[i].prefix) > len(mounts[j].prefix)
+	})
+	return mounts
+}
+
+// reportHostConflict reports the conflict if it's not reported yet,
+// returns the key of conflict
+func (r *Operator) reportHostConflict(trigger, owner *rfv1beta3.Trigger, m *hostMount) string {
+	key := k8sKey(trigger) + "@" + m.key() + "@" + k8sKey(owner)
+	r.hostConflicts.Lock()
+	reported := r.hostConflicts.reported[key]
+	r.hostConflicts.Unlock()
+	if reported {
+		return key
+	}
+
+	klog.Warningf("(httptrigger) %s cannot be mounted at %s%s, which is owned by %s", k8sKey(trigger), m.host, m.prefix, k8sKey(owner))
+	if r.EventRecorder != nil {
+		r.EventRecorder.Eventf(
+			trigger.Ref(),
+			corev1.EventTypeWarning,
+			reasonHostConflict,
+			"%s%s is already served by trigger %s",
+			m.host, m.prefix, k8sKey(owner),
+		)
+	}
+	return key
+}
@@ -0,0 +1,199 @@
+package httptrigger
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
+	"github.com/refunc/refunc/pkg/messages"
+)
+
+var errMalformedResponse = errors.New("h: malformed proxy response")
+
+// https://docs.aws.amazon.com/apigateway/latest/developerguide/set-up-lambda-proxy-integrations.html#api-gateway-simple-proxy-for-lambda-input-format
+
+type V1RequestPayload struct {
+	Version                         string              `json:"version"`
+	Resource                        string              `json:"resource"`
+	Path                            string              `json:"path"`
+	HTTPMethod                      string              `json:"httpMethod"`
+	Headers                         map[string]string   `json:"headers"`
+	MultiValueHeaders               map[string][]string `json:"multiValueHeaders"`
+	QueryStringParameters           map[string]string   `json:"queryStringParameters"`
+	MultiValueQueryStringParameters map[string][]string `json:"multiValueQueryStringParameters"`
+	PathParameters                  map[string]string   `json:"pathParameters"`
+	StageVariables                  map[string]string   `json:"stageVariables"`
+	Context                         V1RequestContext    `json:"requestContext"`
+	Body                            string              `json:"body"`
+	IsBase64Encoded                 bool                `json:"isBase64Encoded"`
+}
+
+type V1RequestContext struct {
+	ResourcePath string                 `json:"resourcePath"`
+	HTTPMethod   string                 `json:"httpMethod"`
+	Path         string                 `json:"path"`
+	Protocol     string                 `json:"protocol"`
+	RequestID    string                 `json:"requestId"`
+	DomainName   string                 `json:"domainName"`
+	Identity     V1RequestIdentity      `json:"identity"`
+	Authorizer   map[string]interface{} `json:"authorizer,omitempty"`
+}
+
+type V1RequestIdentity struct {
+	SourceIP  string `json:"sourceIp"`
+	UserAgent string `json:"userAgent"`
+}
+
+// https://docs.aws.amazon.com/elasticloadbalancing/latest/application/lambda-functions.html#receive-event-from-load-balancer
+
+type ALBRequestPayload struct {
+	Context                         ALBRequestContext   `json:"requestContext"`
+	HTTPMethod                      string              `json:"httpMethod"`
+	Path                            string              `json:"path"`
+	QueryStringParameters           map[string]string   `json:"queryStringParameters"`
+	MultiValueQueryStringParameters map[string][]string `json:"multiValueQueryStringParameters"`
+	Headers                         map[string]string   `json:"headers"`
+	MultiValueHeaders               map[string][]string `json:"multiValueHeaders"`
+	Body                            string              `json:"body"`
+	IsBase64Encoded                 bool                `json:"isBase64Encoded"`
+}
+
+type ALBRequestContext struct {
+	ELB struct {
+		// always empty, triggers are not behind a target group
+		TargetGroupArn string `json:"targetGroupArn"`
+	} `json:"elb"`
+}
+
+// payloadFormat returns the PayloadFormat of trigger, defaults to 2.0
+func payloadFormat(trigger *rfv1beta3.Trigger) string {
+	if trigger.Spec.HTTP == nil || trigger.Spec.HTTP.PayloadFormat == "" {
+		return rfv1beta3.HTTPPayloadFormatV2
+	}
+	return trigger.Spec.HTTP.PayloadFormat
+}
+
+// encodeRequestPayload translates event to args in the payload format of trigger,
+// extra infos for raw payloads are set to options.
+func encodeRequestPayload(
+	trigger *rfv1beta3.Trigger,
+	event RequestPayload,
+	req *http.Request,
+	options map[string]interface{},
+) (json.RawMessage, error) {
+	switch format := payloadFormat(trigger); format {
+	case rfv1beta3.HTTPPayloadFormatV2:
+		return messages.MustFromObject(event), nil
+
+	case rfv1beta3.HTTPPayloadFormatV1:
+		resource := "/{proxy+}"
+		if event.RouteKey != defaultRouteKey {
+			resource = event.RouteKey[strings.Index(event.RouteKey, " ")+1:]
+		}
+		var authorizer map[string]interface{}
+		if a := event.Context.Authorizer; a != nil {
+			authorizer = map[string]interface{}{
+				"type":        a.Type,
+				"principalId": a.PrincipalID,
+				"claims":      a.Claims,
+			}
+		}
+		return messages.MustFromObject(V1RequestPayload{
+			Version:                         rfv1beta3.HTTPPayloadFormatV1,
+			Resource:                        resource,
+			Path:                            req.URL.Path,
+			HTTPMethod:                      req.Method,
+			Headers:                         event.Headers,
+			MultiValueHeaders:               multiValueHeaders(req),
+			QueryStringParameters:           event.QueryStringParameters,
+			MultiValueQueryStringParameters: req.URL.Query(),
+			PathParameters:                  event.PathParameters,
+			StageVariables:                  map[string]string{},
+			Context: V1RequestContext{
+				ResourcePath: resource,
+				HTTPMethod:   req.Method,
+				Path:         req.URL.Path,
+				Protocol:     req.Proto,
+				RequestID:    event.Context.RequestID,
+				DomainName:   req.Host,
+				Identity: V1RequestIdentity{
+					SourceIP:  event.Context.HTTP.SourceIP,
+					UserAgent: event.Context.HTTP.UserAgent,
+				},
+				Authorizer: authorizer,
+			},
+			Body:            event.Body,
+			IsBase64Encoded: event.IsBase64Encoded,
+		}), nil
+
+	case rfv1beta3.HTTPPayloadFormatALB:
+		payload := ALBRequestPayload{
+			HTTPMethod:                      req.Method,
+			Path:                            req.URL.Path,
+			QueryStringParameters:           event.QueryStringParameters,
+			MultiValueQueryStringParameters: req.URL.Query(),
+			Headers:                         event.Headers,
+			MultiValueHeaders:               multiValueHeaders(req),
+			Body:                            event.Body,
+			IsBase64Encoded:                 event.IsBase64Encoded,
+		}
+		return messages.MustFromObject(payload), nil
+
+	case rfv1beta3.HTTPPayloadFormatRaw:
+		options["headers"] = event.Headers
+		options["path"] = event.RawPath
+		options["query"] = event.RawQueryString
+		options["pathParameters"] = event.PathParameters
+		if ct := req.Header.Get("Content-Type"); ct != "" {
+			options["content-type"] = ct
+		}
+		// args must be a valid json, other bodies are passed as json strings
+		if !event.IsBase64Encoded && json.Valid([]byte(event.Body)) {
+			return json.RawMessage(event.Body), nil
+		}
+		options["isBase64Encoded"] = event.IsBase64Encoded
+		return messages.MustFromObject(event.Body), nil
+
+	default:
+		return nil, fmt.Errorf("h: unknown payload format %q", format)
+	}
+}
+
+func multiValueHeaders(req *http.Request) map[string][]string {
+	headers := map[string][]string{}
+	for k, v := range req.Header {
+		headers[k] = v
+	}
+	headers["Host"] = []string{req.Host}
+	return headers
+}
+
+// decodeBody returns the body of a response payload
+func (rsp *ResponsePayload) decodeBody() ([]byte, error) {
+	if rsp.IsBase64Encoded {
+		return base64.StdEncoding.DecodeString(rsp.Body)
+	}
+	return []byte(rsp.Body), nil
+}
+
+// header merges Headers and MultiValueHeaders of a response payload
+func (rsp *ResponsePayload) header() http.Header {
+	header := http.Header{}
+	for k, v := range rsp.Headers {
+		header.Set(k, v)
+	}
+	for k, vs := range rsp.MultiValueHeaders {
+		header.Del(k)
+		for _, v := range vs {
+			header.Add(k, v)
+		}
+	}
+	for _, cookie := range rsp.Cookies {
+		header.Add("Set-Cookie", cookie)
+	}
+	return header
+}
@@ -0,0 +1,114 @@
+package httptrigger
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
+)
+
+func Test_encodeRequestPayload(t *testing.T) {
+	tests := []struct {
+		format  string
+		body    string
+		want    map[string]interface{}
+		options map[string]interface{}
+	}{
+		{
+			format: rfv1beta3.HTTPPayloadFormatV1,
+			body:   "hello",
+			want: map[string]interface{}{
+				"version":    "1.0",
+				"resource":   "/users/{id}",
+				"httpMethod": "POST",
+				"path":       "/ns/fn/users/1",
+				"body":       "hello",
+			},
+		},
+		{
+			format: rfv1beta3.HTTPPayloadFormatALB,
+			body:   "hello",
+			want: map[string]interface{}{
+				"httpMethod": "POST",
+				"path":       "/ns/fn/users/1",
+				"body":       "hello",
+			},
+		},
+		{
+			format:  rfv1beta3.HTTPPayloadFormatRaw,
+			body:    `{"name":"refunc"}`,
+			want:    map[string]interface{}{"name": "refunc"},
+			options: map[string]interface{}{"path": "/ns/fn/users/1", "query": "a=1&a=2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			trigger := &rfv1beta3.Trigger{Spec: rfv1beta3.TriggerSpec{
+				TriggerConfig: rfv1beta3.TriggerConfig{
+					HTTP: &rfv1beta3.HTTPTrigger{PayloadFormat: tt.format},
+				},
+			}}
+			req := httptest.NewRequest("POST", "/ns/fn/users/1?a=1&a=2", strings.NewReader(tt.body))
+			event, err := formatRequestPayload(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			event.RouteKey = "POST /users/{id}"
+
+			options := map[string]interface{}{}
+			args, err := encodeRequestPayload(trigger, event, req, options)
+			if err != nil {
+				t.Fatalf("encodeRequestPayload() error = %v", err)
+			}
+			var got map[string]interface{}
+			if err := json.Unmarshal(args, &got); err != nil {
+				t.Fatal(err)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("args[%q] = %v, want %v", k, got[k], v)
+				}
+			}
+			for k, v := range tt.options {
+				if options[k] != v {
+					t.Errorf("options[%q] = %v, want %v", k, options[k], v)
+				}
+			}
+		})
+	}
+}
+
+func Test_formatResponsePayload(t *testing.T) {
+	tests := []struct {
+		format  string
+		result  string
+		code    int
+		header  string
+		wantErr bool
+	}{
+		{rfv1beta3.HTTPPayloadFormatV2, `{"msg":"hi"}`, 200, jsonCT, false},
+		{rfv1beta3.HTTPPayloadFormatV1, `{"statusCode":201,"multiValueHeaders":{"Content-Type":["text/csv"]}}`, 201, "text/csv", false},
+		{rfv1beta3.HTTPPayloadFormatV1, `{"msg":"hi"}`, 0, "", true},
+		{rfv1beta3.HTTPPayloadFormatALB, `{"statusCode":404,"headers":{"Content-Type":"text/plain"}}`, 404, "text/plain", false},
+		{rfv1beta3.HTTPPayloadFormatRaw, `{"statusCode":404}`, 200, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format+" "+tt.result, func(t *testing.T) {
+			rsp, err := formatResponsePayload(tt.format, []byte(tt.result))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("formatResponsePayload() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if rsp.StatusCode != tt.code {
+				t.Errorf("StatusCode = %d, want %d", rsp.StatusCode, tt.code)
+			}
+			if got := rsp.header().Get("Content-Type"); got != tt.header {
+				t.Errorf("Content-Type = %q, want %q", got, tt.header)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package httptrigger
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/klog"
+
+	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
+	"github.com/refunc/refunc/pkg/operators/triggers/commontrigger"
+	"github.com/refunc/refunc/pkg/utils/rfutil"
+)
+
+const (
+	// limiters not used for a while are dropped
+	limiterIdleTimeout   = 5 * time.Minute
+	limiterCleanInterval = time.Minute
+	// max number of limiters, new keys of a trigger share one limiter once exceeded
+	maxLimiterEntries = 100000
+)
+
+type limiterEntry struct {
+	limiter  *rate.Limiter
+	inFlight int64
+	lastSeen time.Time
+}
+
+type rateLimiters struct {
+	mu      sync.Mutex
+	entries map[string]*limiterEntry
+
+	// requests from trusted proxies are keyed by the client in X-Forwarded-For
+	trustedProxies []*net.IPNet
+}
+
+// admit checks if a request of trigger can be served, returns a func to release
+// the request once finished, or the duration to wait before retrying.
+func (rl *rateLimiters) admit(
+	trigger *rfv1beta3.Trigger,
+	req *http.Request,
+	authorizer *RequestContextAuthorizer,
+) (release func(), retryAfter time.Duration, ok bool) {
+	release = func() {}
+	cfg := rateLimitConfig(trigger)
+	if cfg == nil || (cfg.RPS <= 0 && cfg.MaxInFlight <= 0) {
+		return release, 0, true
+	}
+
+	// config hash is part of key, so that updated limits take effect immediately
+	base := k8sKey(trigger) + "/" + rfutil.GetMD5Hash(cfg) + "/"
+	key := base + rl.limiterSubKey(cfg, req, authorizer)
+
+	rl.mu.Lock()
+	if rl.entries == nil {
+		rl.entries = make(map[string]*limiterEntry)
+	}
+	entry, found := rl.entries[key]
+	if !found && len(rl.entries) >= maxLimiterEntries {
+		klog.V(3).Infof("(h) too many limiters, %s shares the overflow limiter", key)
+		key = base + "*"
+		entry, found = rl.entries[key]
+	}
+	if !found {
+		limit, burst := rate.Inf, 0
+		if cfg.RPS > 0 {
+			limit, burst = rate.Limit(cfg.RPS), cfg.RPS
+			if cfg.Burst > 0 {
+				burst = cfg.Burst
+			}
+		}
+		entry = &limiterEntry{limiter: rate.NewLimiter(limit, burst)}
+		rl.entries[key] = entry
+	}
+	entry.lastSeen = time.Now()
+	if cfg.MaxInFlight > 0 {
+		// counted with lock held, so the entry is never dropped while in use
+		if entry.inFlight >= int64(cfg.MaxInFlight) {
+			rl.mu.Unlock()
+			return release, time.Second, false
+		}
+		entry.inFlight++
+		var once sync.Once
+		release = func() {
+			once.Do(func() {
+				rl.mu.Lock()
+				entry.inFlight--
+				rl.mu.Unlock()
+			})
+		}
+	}
+	rl.mu.Unlock()
+
+	if cfg.RPS > 0 {
+		r := entry.limiter.Reserve()
+		if delay := r.Delay(); !r.OK() || delay > 0 {
+			r.Cancel()
+			release()
+			if delay <= 0 || delay == rate.InfDuration {
+				delay = time.Second
+			}
+			return func() {}, delay, false
+		}
+	}
+
+	return release, 0, true
+}
+
+// clean drops idle limiters until stopC is closed.
+func (rl *rateLimiters) clean(stopC <-chan struct{}) {
+	ticker := time.NewTicker(limiterCleanInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopC:
+			return
+		case <-ticker.C:
+			deadline := time.Now().Add(-limiterIdleTimeout)
+			rl.mu.Lock()
+			for key, entry := range rl.entries {
+				if entry.inFlight <= 0 && entry.lastSeen.Before(deadline) {
+					klog.V(4).Infof("(h) drop idle limiter %s", key)
+					delete(rl.entries, key)
+				}
+			}
+			rl.mu.Unlock()
+		}
+	}
+}
+
+// rateLimitConfig returns the rate limit of a http or common trigger
+func rateLimitConfig(trigger *rfv1beta3.Trigger) *rfv1beta3.HTTPTriggerRateLimit {
+	switch {
+	case trigger.Spec.Type == commontrigger.Type && trigger.Spec.Common != nil:
+		return trigger.Spec.Common.RateLimit
+	case trigger.Spec.HTTP != nil:
+		return trigger.Spec.HTTP.RateLimit
+	}
+	return nil
+}
+
+func (rl *rateLimiters) limiterSubKey(cfg *rfv1beta3.HTTPTriggerRateLimit, req *http.Request, authorizer *RequestContextAuthorizer) string {
+	switch cfg.KeyBy {
+	case rfv1beta3.RateLimitKeyBySubject:
+		if authorizer != nil && authorizer.PrincipalID != "" {
+			return "sub:" + authorizer.PrincipalID
+		}
+		// fallback to source ip for anonymous requests, or principals without id
+		fallthrough
+	case rfv1beta3.RateLimitKeyBySourceIP:
+		return "ip:" + rl.sourceIP(req)
+	}
+	return ""
+}
+
+// sourceIP returns the peer of request, or the nearest untrusted hop in X-Forwarded-For
+// if the peer is a trusted proxy, since X-Forwarded-For is set by clients.
+func (rl *rateLimiters) sourceIP(req *http.Request) string {
+	addr := peerAddr(req)
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	if !rl.isTrustedProxy(host) {
+		return host
+	}
+	hops := strings.Split(strings.Join(req.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		host = hop
+		if !rl.isTrustedProxy(hop) {
+			break
+		}
+	}
+	return host
+}
+
+func (rl *rateLimiters) isTrustedProxy(host string) bool {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, cidr := range rl.trustedProxies {
+		if cidr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+type peerAddrKey struct{}
+
+// withPeerAddr saves the address of peer before it's rewritten by handlers.ProxyHeaders
+func withPeerAddr(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		h.ServeHTTP(rw, req.WithContext(context.WithValue(req.Context(), peerAddrKey{}, req.RemoteAddr)))
+	})
+}
+
+func peerAddr(req *http.Request) string {
+	if addr, ok := req.Context().Value(peerAddrKey{}).(string); ok {
+		return addr
+	}
+	return req.RemoteAddr
+}
+
+func writeTooManyRequests(rw http.ResponseWriter, retryAfter time.Duration) {
+	rw.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	writeHTTPError(rw, http.StatusTooManyRequests, "h: too many requests")
+}
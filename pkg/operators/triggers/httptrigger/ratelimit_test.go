@@ -0,0 +1,113 @@
+package httptrigger
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/handlers"
+	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
+)
+
+func Test_rateLimiters_admit(t *testing.T) {
+	newTrigger := func(cfg *rfv1beta3.HTTPTriggerRateLimit) *rfv1beta3.Trigger {
+		tr := &rfv1beta3.Trigger{}
+		tr.Namespace, tr.Name = "ns", "tr"
+		tr.Spec.HTTP = &rfv1beta3.HTTPTrigger{RateLimit: cfg}
+		return tr
+	}
+
+	tests := []struct {
+		name    string
+		cfg     *rfv1beta3.HTTPTriggerRateLimit
+		addrs   []string
+		release bool
+		want    []bool
+	}{
+		{"unlimited", nil, []string{"a:1", "a:1", "a:1"}, false, []bool{true, true, true}},
+		{"in-flight", &rfv1beta3.HTTPTriggerRateLimit{MaxInFlight: 2}, []string{"a:1", "a:1", "a:1"}, false, []bool{true, true, false}},
+		{"in-flight released", &rfv1beta3.HTTPTriggerRateLimit{MaxInFlight: 1}, []string{"a:1", "a:1", "a:1"}, true, []bool{true, true, true}},
+		{"rps", &rfv1beta3.HTTPTriggerRateLimit{RPS: 1, Burst: 2}, []string{"a:1", "a:1", "a:1"}, true, []bool{true, true, false}},
+		{"rps by ip", &rfv1beta3.HTTPTriggerRateLimit{RPS: 1, KeyBy: rfv1beta3.RateLimitKeyBySourceIP}, []string{"a:1", "b:1", "a:2"}, true, []bool{true, true, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rl rateLimiters
+			trigger := newTrigger(tt.cfg)
+			for i, addr := range tt.addrs {
+				req := httptest.NewRequest("GET", "/ns/fn", nil)
+				req.RemoteAddr = addr
+				release, retryAfter, ok := rl.admit(trigger, req, nil)
+				if ok != tt.want[i] {
+					t.Fatalf("#%d admit() = %v, want %v", i, ok, tt.want[i])
+				}
+				if !ok && retryAfter <= 0 {
+					t.Errorf("#%d admit() retryAfter = %v", i, retryAfter)
+				}
+				if tt.release {
+					release()
+				}
+			}
+		})
+	}
+}
+
+func Test_rateLimiters_sourceIP(t *testing.T) {
+	_, proxies, _ := net.ParseCIDR("10.0.0.0/8")
+	rl := &rateLimiters{trustedProxies: []*net.IPNet{proxies}}
+
+	tests := []struct {
+		name string
+		peer string
+		xff  string
+		want string
+	}{
+		{"direct", "1.2.3.4:80", "", "1.2.3.4"},
+		{"spoofed by client", "1.2.3.4:80", "5.6.7.8", "1.2.3.4"},
+		{"trusted proxy", "10.0.0.1:80", "5.6.7.8", "5.6.7.8"},
+		{"spoofed behind proxy", "10.0.0.1:80", "9.9.9.9, 5.6.7.8, 10.0.0.2", "5.6.7.8"},
+		{"proxy without header", "10.0.0.1:80", "", "10.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/ns/fn", nil)
+			req.RemoteAddr = tt.peer
+			if tt.xff != "" {
+				req.Header.Set("X-Forwarded-For", tt.xff)
+			}
+			var got string
+			// RemoteAddr is rewritten from X-Forwarded-For by proxy headers
+			withPeerAddr(handlers.ProxyHeaders(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+				got = rl.sourceIP(req)
+			}))).ServeHTTP(httptest.NewRecorder(), req)
+			if got != tt.want {
+				t.Errorf("sourceIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_rateLimiters_limiterSubKey(t *testing.T) {
+	rl := &rateLimiters{}
+	cfg := &rfv1beta3.HTTPTriggerRateLimit{KeyBy: rfv1beta3.RateLimitKeyBySubject}
+
+	tests := []struct {
+		name       string
+		authorizer *RequestContextAuthorizer
+		want       string
+	}{
+		{"subject", &RequestContextAuthorizer{Type: "jwt", PrincipalID: "alice"}, "sub:alice"},
+		{"anonymous", nil, "ip:1.2.3.4"},
+		{"no subject", &RequestContextAuthorizer{Type: "jwt"}, "ip:1.2.3.4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/ns/fn", nil)
+			req.RemoteAddr = "1.2.3.4:80"
+			if got := rl.limiterSubKey(cfg, req, tt.authorizer); got != tt.want {
+				t.Errorf("limiterSubKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package httptrigger
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	// routeKey of requests when no routes is configured
+	defaultRouteKey = "$default"
+
+	anyMethod = "ANY"
+)
+
+var knownMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodOptions: true,
+	anyMethod:          true,
+}
+
+// parseRoute parses route template in form of "[METHOD ]/path/{var}",
+// method is ANY if omitted.
+func parseRoute(route string) (method, tmpl string, err error) {
+	fields := strings.Fields(route)
+	switch len(fields) {
+	case 1:
+		method, tmpl = anyMethod, fields[0]
+	case 2:
+		method, tmpl = strings.ToUpper(fields[0]), fields[1]
+	default:
+		return "", "", fmt.Errorf("malformed route %q", route)
+	}
+	if !knownMethods[method] {
+		return "", "", fmt.Errorf("unknown method %q", method)
+	}
+	if !strings.HasPrefix(tmpl, "/") {
+		return "", "", fmt.Errorf("path %q must start with /", tmpl)
+	}
+	return method, tmpl, nil
+}
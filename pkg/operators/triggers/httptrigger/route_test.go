@@ -0,0 +1,161 @@
+package httptrigger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/gorilla/mux"
+	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
+	rflistersv1 "github.com/refunc/refunc/pkg/generated/listers/refunc/v1beta3"
+	"github.com/refunc/refunc/pkg/operators"
+)
+
+func Test_parseRoute(t *testing.T) {
+	tests := []struct {
+		route   string
+		method  string
+		tmpl    string
+		wantErr bool
+	}{
+		{"GET /users/{id}", "GET", "/users/{id}", false},
+		{"post  /orders", "POST", "/orders", false},
+		{"/orders", "ANY", "/orders", false},
+		{"GET users", "", "", true},
+		{"FETCH /users", "", "", true},
+		{"GET /a /b", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.route, func(t *testing.T) {
+			method, tmpl, err := parseRoute(tt.route)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRoute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if method != tt.method || tmpl != tt.tmpl {
+				t.Errorf("parseRoute() = %q, %q, want %q, %q", method, tmpl, tt.method, tt.tmpl)
+			}
+		})
+	}
+}
+
+func Test_httpHandler_routes(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	indexer.Add(&rfv1beta3.Trigger{ // nolint:errcheck
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "fn"},
+		Spec: rfv1beta3.TriggerSpec{
+			FuncName: "fn",
+			Type:     Type,
+			TriggerConfig: rfv1beta3.TriggerConfig{
+				HTTP: &rfv1beta3.HTTPTrigger{Routes: []string{"GET /users/{id}", "/orders"}},
+			},
+		},
+	})
+
+	r := &Operator{BaseOperator: &operators.BaseOperator{
+		TriggerLister: rflistersv1.NewTriggerLister(indexer),
+		FuncdefLister: rflistersv1.NewFuncdefLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})),
+	}}
+	h := &httpHandler{fndKey: "ns/fn", ns: "ns", name: "fn", operator: r}
+	router := mux.NewRouter()
+	h.setupHTTPEndpoints(router)
+
+	tests := []struct {
+		method string
+		path   string
+		code   int
+		// matched requests reach the handler, which fails to resolve the funcdef
+		matched bool
+	}{
+		{"GET", "/ns/fn/users/1", http.StatusNotFound, true},
+		{"DELETE", "/ns/fn/orders", http.StatusNotFound, true},
+		{"GET", "/ns/fn/_stream/users/1", http.StatusNotFound, true},
+		{"POST", "/ns/fn/users/1", http.StatusMethodNotAllowed, false},
+		{"GET", "/ns/fn/users", http.StatusNotFound, false},
+		{"GET", "/ns/fn", http.StatusNotFound, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			rw := httptest.NewRecorder()
+			router.ServeHTTP(rw, httptest.NewRequest(tt.method, tt.path, nil))
+			if rw.Code != tt.code {
+				t.Errorf("code = %d, want %d", rw.Code, tt.code)
+			}
+			if matched := strings.Contains(rw.Body.String(), "funcdef"); matched != tt.matched {
+				t.Errorf("matched = %v, want %v, body %q", matched, tt.matched, rw.Body.String())
+			}
+		})
+	}
+}
+
+func TestOperator_resolveHostMounts(t *testing.T) {
+	newTrigger := func(name string, created int64, prefix string, hosts ...string) *rfv1beta3.Trigger {
+		return &rfv1beta3.Trigger{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name, CreationTimestamp: metav1.Unix(created, 0)},
+			Spec: rfv1beta3.TriggerSpec{
+				FuncName: name,
+				Type:     Type,
+				TriggerConfig: rfv1beta3.TriggerConfig{
+					HTTP: &rfv1beta3.HTTPTrigger{Hosts: hosts, PathPrefix: prefix},
+				},
+			},
+		}
+	}
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	indexer.Add(newTrigger("newer", 2, "/", "api.example.com"))          // nolint:errcheck
+	indexer.Add(newTrigger("older", 1, "", "API.example.com"))           // nolint:errcheck
+	indexer.Add(newTrigger("users", 3, "/v1/users/", "api.example.com")) // nolint:errcheck
+
+	recorder := record.NewFakeRecorder(10)
+	r := &Operator{
+		BaseOperator:  &operators.BaseOperator{TriggerLister: rflistersv1.NewTriggerLister(indexer)},
+		EventRecorder: recorder,
+	}
+	var handlers []*httpHandler
+	for _, name := range []string{"newer", "older", "users"} {
+		handlers = append(handlers, &httpHandler{fndKey: "ns/" + name, ns: "ns", name: name, operator: r})
+	}
+
+	mounts := r.resolveHostMounts(handlers)
+	var got []string
+	for _, m := range mounts {
+		got = append(got, m.handler.name+"@"+m.key())
+	}
+	want := []string{"users@api.example.com/v1/users", "older@api.example.com/"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("resolveHostMounts() = %v, want %v", got, want)
+	}
+
+	// resync, the conflict of newer is reported only once
+	r.resolveHostMounts(handlers)
+	if n := len(recorder.Events); n != 1 {
+		t.Errorf("%d conflict events, want 1", n)
+	}
+}
+
+func Test_hostMount_subrouter(t *testing.T) {
+	m := &hostMount{host: "api.example.com", prefix: "/api"}
+	router := mux.NewRouter()
+	m.subrouter(router).PathPrefix("").HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/api", true},
+		{"/api/", true},
+		{"/api/users", true},
+		{"/apix", false},
+		{"/", false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "http://api.example.com"+tt.path, nil)
+		if got := router.Match(req, &mux.RouteMatch{}); got != tt.want {
+			t.Errorf("%s matched = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package httptrigger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"k8s.io/klog"
+
+	"github.com/refunc/refunc/pkg/client"
+	"github.com/refunc/refunc/pkg/messages"
+)
+
+const (
+	streamPingInterval = 15 * time.Second
+
+	sseCT = "text/event-stream; charset=utf-8"
+)
+
+var ssePingMsg = []byte(": ping\n\n")
+
+// streamTask pushes logs and messages of a task to client as they arrive, then the result.
+//
+// Actions are written as Server-Sent Events if client accepts text/event-stream,
+// otherwise as CRLF delimited actions using chunked transfer.
+func (t *httpHandler) streamTask(ctx context.Context, rw http.ResponseWriter, req *http.Request, taskr client.TaskResolver) {
+	sse := strings.Contains(req.Header.Get("Accept"), "text/event-stream")
+
+	if sse {
+		rw.Header().Set("Content-Type", sseCT)
+	} else {
+		rw.Header().Set("Content-Type", jsonCT)
+	}
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.Header().Set("X-Content-Type-Options", "nosniff")
+	rw.WriteHeader(http.StatusOK)
+	flushRW(rw)
+
+	write := t.flushWriter(rw, taskr.ID())
+	send := func(typ messages.MessageType, payload json.RawMessage) bool {
+		if sse {
+			var buf bytes.Buffer
+			buf.WriteString("event: ")
+			buf.WriteString(string(typ))
+			buf.WriteString("\ndata: ")
+			buf.Write(payload)
+			buf.WriteString("\n\n")
+			return write(buf.Bytes())
+		}
+		bts, _ := json.Marshal(messages.Action{Type: typ, Payload: payload})
+		return write(append(bts, messages.TokenCRLF...))
+	}
+
+	logs, msgs := taskr.LogObserver(), taskr.MsgObserver()
+	drain := func() bool {
+		for logs.HasNext() {
+			if !send(messages.Log, messages.MustFromObject(logs.Next())) {
+				return false
+			}
+		}
+		for msgs.HasNext() {
+			if !send(messages.Emit, toRawMessage(msgs.Next())) {
+				return false
+			}
+		}
+		return true
+	}
+
+	ticker := time.NewTicker(streamPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			klog.V(3).Infof("(h) %s stream closed, %v", taskr.ID(), ctx.Err())
+			return
+
+		case <-ticker.C:
+			ping := messages.PingMsg
+			if sse {
+				ping = ssePingMsg
+			}
+			if !write(ping) {
+				return
+			}
+
+		case <-logs.Changes():
+			if !drain() {
+				return
+			}
+
+		case <-msgs.Changes():
+			if !drain() {
+				return
+			}
+
+		case <-taskr.Done():
+			if !drain() {
+				return
+			}
+			bts, err := taskr.Result()
+			if err != nil {
+				send(messages.Error, messages.MustFromObject(messages.GetErrorMessage(err)))
+				return
+			}
+			send(messages.Response, toRawMessage(bts))
+			return
+		}
+	}
+}
+
+// toRawMessage converts value from observers to a single line json
+func toRawMessage(v interface{}) json.RawMessage {
+	var bts []byte
+	switch val := v.(type) {
+	case json.RawMessage:
+		bts = val
+	case []byte:
+		bts = val
+	default:
+		return messages.MustFromObject(val)
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, bytes.TrimSpace(bts)); err != nil {
+		return messages.MustFromObject(string(bts))
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,75 @@
+package httptrigger
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	observer "github.com/refunc/go-observer"
+)
+
+type fakeTask struct {
+	done chan struct{}
+	// closed once observers are subscribed
+	observed chan struct{}
+	logs     observer.Property
+	msgs     observer.Property
+	data     []byte
+	err      error
+}
+
+func (f *fakeTask) ID() string                   { return "id" }
+func (f *fakeTask) Name() string                 { return "fake" }
+func (f *fakeTask) Done() <-chan struct{}        { return f.done }
+func (f *fakeTask) Cancel()                      {}
+func (f *fakeTask) Result() ([]byte, error)      { return f.data, f.err }
+func (f *fakeTask) LogObserver() observer.Stream { return f.logs.Observe() }
+func (f *fakeTask) MsgObserver() observer.Stream {
+	defer close(f.observed)
+	return f.msgs.Observe()
+}
+func (f *fakeTask) StatJSON() string { return "" }
+
+func Test_httpHandler_streamTask(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept string
+		want   string
+	}{
+		{"chunked", "", "{\"a\":\"log\",\"p\":\"hello\"}\r\n{\"a\":\"rsp\",\"p\":{\"ok\":true}}\r\n"},
+		{"sse", "text/event-stream", "event: log\ndata: \"hello\"\n\nevent: rsp\ndata: {\"ok\":true}\n\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := &fakeTask{
+				done:     make(chan struct{}),
+				observed: make(chan struct{}),
+				logs:     observer.NewProperty(nil),
+				msgs:     observer.NewProperty(nil),
+				data:     []byte("{\n  \"ok\": true\n}"),
+			}
+			req := httptest.NewRequest("GET", "/ns/fn/_stream", nil)
+			req.Header.Set("Accept", tt.accept)
+			rw := httptest.NewRecorder()
+
+			h := &httpHandler{}
+			finished := make(chan struct{})
+			go func() {
+				defer close(finished)
+				h.streamTask(context.Background(), rw, req, task)
+			}()
+			<-task.observed
+			task.logs.Update("hello")
+			close(task.done)
+			<-finished
+
+			if got := rw.Body.String(); got != tt.want {
+				t.Errorf("streamTask() = %q, want %q", got, tt.want)
+			}
+			if !strings.HasPrefix(rw.Header().Get("Content-Type"), map[bool]string{true: "text/event-stream", false: "application/json"}[tt.accept != ""]) {
+				t.Errorf("streamTask() Content-Type = %q", rw.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package httptrigger
 
 import (
 	"fmt"
+	"net/http"
 	"time"
 
 	"k8s.io/klog"
@@ -13,9 +14,14 @@ import (
 )
 
 // ensureTask gets or creates a client.TaskResolver
-func (t *httpHandler) ensureTask(fndef *rfv1beta3.Funcdef, trigger *rfv1beta3.Trigger, request *messages.InvokeRequest) (client.TaskResolver, error) {
-	id := request.RequestID
-	tr, _, retErr := t.operator.liveTasks.GetOrCreateTask(id, func() (_ client.TaskResolver, err error) {
+func (t *httpHandler) ensureTask(
+	id string,
+	fndef *rfv1beta3.Funcdef,
+	trigger *rfv1beta3.Trigger,
+	request *messages.InvokeRequest,
+	cp *cachePolicy,
+) (client.TaskResolver, error) {
+	tr, created, retErr := t.operator.liveTasks.GetOrCreateTask(id, func() (_ client.TaskResolver, err error) {
 		defer func() {
 			re := recover()
 			if err != nil || re != nil {
@@ -47,21 +53,14 @@ func (t *httpHandler) ensureTask(fndef *rfv1beta3.Funcdef, trigger *rfv1beta3.Tr
 		ctx = client.WithLogger(ctx, klog.V(2))
 		ctx = client.WithTimeoutHint(ctx, timeout)
 		ctx = client.WithLoggingHint(ctx, fwdLogs)
-		endpoint := trigger.Namespace + "/" + trigger.Spec.FuncName
+		// fndef is the version picked by traffic split
+		endpoint := fndef.Namespace + "/" + fndef.Name
 		tr, err := client.NewTaskResolver(ctx, endpoint, request)
 		if err != nil {
+			t.operator.ObserveFuncdef(trigger, fndef, true)
 			return
 		}
 
-		// find if request is enabeld cache
-		cacheEnabled := func() bool {
-			if v, ok := request.Options["cachePreferred"]; ok {
-				cached, ok := v.(bool)
-				return ok && cached
-			}
-			return false
-		}()
-
 		// handle task result
 		go func() {
 			defer func() {
@@ -70,14 +69,25 @@ func (t *httpHandler) ensureTask(fndef *rfv1beta3.Funcdef, trigger *rfv1beta3.Tr
 			}()
 			<-tr.Done()
 			bts, err := tr.Result()
-			if cacheEnabled && err == nil {
-				klog.V(3).Infof("(h) %s set cache", tr.Name())
-				t.operator.http.cache.Set(id, bts) //nolint:errcheck
+			t.operator.ObserveFuncdef(trigger, fndef, err != nil)
+			if cp != nil && err == nil {
+				t.operator.storeResult(cp, payloadFormat(trigger), bts)
 			}
 		}()
 
 		return tr, nil
 	})
 
+	if !created && retErr == nil {
+		klog.V(3).Infof("(h) %s attached to in-flight task %s", request.RequestID, tr.Name())
+	}
+
 	return tr, retErr
 }
+
+// collapseKey returns the task id shared by identical requests,
+// credentials are always part of the key to not leak results between callers.
+func collapseKey(fnHash string, req *http.Request, varyHeaders []string, body string) string {
+	headers := append([]string{"Authorization", "Cookie"}, varyHeaders...)
+	return utils.GenID([]byte(cacheKey(fnHash, req, headers)), []byte(body))
+}
@@ -3,14 +3,18 @@ package httptrigger
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"sync"
 	"time"
 
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sinformers "k8s.io/client-go/informers"
+	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog"
 
 	"github.com/allegro/bigcache"
@@ -19,6 +23,7 @@ import (
 	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
 	rfcli "github.com/refunc/refunc/pkg/generated/clientset/versioned"
 	informers "github.com/refunc/refunc/pkg/generated/informers/externalversions"
+	rflistersv1 "github.com/refunc/refunc/pkg/generated/listers/refunc/v1beta3"
 	"github.com/refunc/refunc/pkg/messages"
 	operators "github.com/refunc/refunc/pkg/operators"
 	"github.com/refunc/refunc/pkg/operators/triggers/httptrigger/mmux"
@@ -43,12 +48,33 @@ type Operator struct {
 	}
 	corsOpts []handlers.CORSOption
 
+	// TrustedProxies are CIDRs of proxies whose X-Forwarded-For is trusted by rate limits
+	TrustedProxies []string
+
+	// EventRecorder reports problems of triggers, optional
+	EventRecorder record.EventRecorder
+
 	ctx context.Context
 
 	triggers sync.Map
 
 	liveTasks operators.LiveTaskStore
 
+	secretLister corelisters.SecretLister
+	fniLister    rflistersv1.FuncinstLister
+
+	limiters rateLimiters
+
+	// conflicts of host mounts that have been reported
+	hostConflicts struct {
+		sync.Mutex
+		reported map[string]bool
+	}
+
+	// async tasks not yet persisted
+	asyncTasks sync.Map
+	asyncStore taskStore
+
 	// http endpoints
 	http struct {
 		router *mmux.MutableRouter
@@ -65,6 +91,7 @@ func NewOperator(
 	cfg *rest.Config,
 	rclient rfcli.Interface,
 	rfInformers informers.SharedInformerFactory,
+	kubeInformers k8sinformers.SharedInformerFactory,
 ) (*Operator, error) {
 	base, err := operators.NewBaseOperator(cfg, rclient, rfInformers)
 	if err != nil {
@@ -75,7 +102,14 @@ func NewOperator(
 		BaseOperator: base,
 		ctx:          ctx,
 		liveTasks:    operators.NewLiveTaskStore(),
+		asyncStore:   s3TaskStore{},
+		secretLister: kubeInformers.Core().V1().Secrets().Lister(),
+		fniLister:    rfInformers.Refunc().V1beta3().Funcinsts().Lister(),
 	}
+	r.WantedInformers = append(r.WantedInformers,
+		kubeInformers.Core().V1().Secrets().Informer().HasSynced,
+		rfInformers.Refunc().V1beta3().Funcinsts().Informer().HasSynced,
+	)
 
 	r.http.router = mmux.NewMutableRouter()
 	r.http.cache = new(disabledCache) // lazily creates bigcache in Run()
@@ -131,6 +165,7 @@ func (r *Operator) Run(stopC <-chan struct{}) {
 
 	klog.Info("(httptrigger) starting http server")
 	go r.listenAndServe()
+	go r.limiters.clean(stopC)
 
 	<-stopC
 	klog.Info("(httptrigger) shuting down http trigger operator")
@@ -204,10 +239,22 @@ func (r *Operator) handleTriggerDelete(o interface{}) {
 
 func (r *Operator) popluateEndpoints() {
 	router := mux.NewRouter()
+
+	var handlers []*httpHandler
 	r.triggers.Range(func(_, value interface{}) bool {
-		value.(*httpHandler).setupHTTPEndpoints(router)
+		handlers = append(handlers, value.(*httpHandler))
 		return true
 	})
+
+	r.setupCommonTriggers(router)
+
+	// custom hosts are more specific than the default mounts
+	for _, m := range r.resolveHostMounts(handlers) {
+		m.handler.setupRoutes(m.subrouter(router))
+	}
+	for _, h := range handlers {
+		h.setupHTTPEndpoints(router)
+	}
 	// swap
 	r.http.router.UpdateRouter(router)
 }
@@ -231,6 +278,15 @@ func (r *Operator) listenAndServe() {
 	// setup handlers
 	var handler http.Handler = r.http.router
 
+	for _, cidr := range r.TrustedProxies {
+		_, ipnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			klog.Warningf("(httptrigger) invalid trusted proxy %q, %v", cidr, err)
+			continue
+		}
+		r.limiters.trustedProxies = append(r.limiters.trustedProxies, ipnet)
+	}
+
 	// config cors
 	var corsOpts []handlers.CORSOption
 	if r.CORS.AllowCredentials {
@@ -261,6 +317,7 @@ func (r *Operator) listenAndServe() {
 
 	// handle proxy
 	handler = handlers.ProxyHeaders(handler)
+	handler = withPeerAddr(handler)
 
 	server := &http.Server{
 		Addr:    url,
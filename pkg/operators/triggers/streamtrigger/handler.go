@@ -0,0 +1,290 @@
+package streamtrigger
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog"
+
+	nats "github.com/nats-io/nats.go"
+	"github.com/nats-io/nuid"
+	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
+	"github.com/refunc/refunc/pkg/client"
+	"github.com/refunc/refunc/pkg/messages"
+	"github.com/refunc/refunc/pkg/utils"
+)
+
+const codeLaunchBias = 10 * time.Second
+
+type streamHandler struct {
+	trKey string
+	ns    string
+	name  string
+
+	cfg rfv1beta3.StreamTrigger
+
+	sub    *nats.Subscription
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	operator *Operator
+}
+
+// streamConfig returns config of trigger with defaults
+func streamConfig(trigger *rfv1beta3.Trigger) rfv1beta3.StreamTrigger {
+	cfg := *trigger.Spec.Stream
+	if cfg.Durable == "" {
+		cfg.Durable = strings.ReplaceAll(trigger.Namespace+"_"+trigger.Name, ".", "_")
+	}
+	if cfg.DeliverPolicy == "" {
+		cfg.DeliverPolicy = rfv1beta3.StreamDeliverNew
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 10
+	}
+	if cfg.MaxBatchingWindowMs <= 0 {
+		cfg.MaxBatchingWindowMs = 1000
+	}
+	if cfg.MaxInflight <= 0 {
+		cfg.MaxInflight = 1
+	}
+	if cfg.MaxDeliver == 0 {
+		cfg.MaxDeliver = 5
+	}
+	if cfg.AckWaitSeconds <= 0 {
+		cfg.AckWaitSeconds = 30
+	}
+	return cfg
+}
+
+// validateStream checks stream is owned by namespace ns,
+// streams of all namespaces live in the same JetStream account.
+func validateStream(ns, stream string) error {
+	if !strings.HasPrefix(stream, ns+"_") {
+		return fmt.Errorf("stream %q is not owned by namespace %q, it must be prefixed by %q", stream, ns, ns+"_")
+	}
+	return nil
+}
+
+// consumerConfig returns config of the durable consumer
+func consumerConfig(cfg rfv1beta3.StreamTrigger) (*nats.ConsumerConfig, error) {
+	cc := &nats.ConsumerConfig{
+		Durable:       cfg.Durable,
+		AckPolicy:     nats.AckExplicitPolicy,
+		AckWait:       time.Duration(cfg.AckWaitSeconds) * time.Second,
+		MaxDeliver:    cfg.MaxDeliver,
+		FilterSubject: cfg.Subject,
+		MaxAckPending: cfg.BatchSize * cfg.MaxInflight,
+	}
+	switch cfg.DeliverPolicy {
+	case rfv1beta3.StreamDeliverAll:
+		cc.DeliverPolicy = nats.DeliverAllPolicy
+	case rfv1beta3.StreamDeliverLast:
+		cc.DeliverPolicy = nats.DeliverLastPolicy
+	case rfv1beta3.StreamDeliverNew:
+		cc.DeliverPolicy = nats.DeliverNewPolicy
+	default:
+		return nil, fmt.Errorf("unknown deliver policy %q", cfg.DeliverPolicy)
+	}
+	return cc, nil
+}
+
+// consumerChanged returns true if the consumer should be recreated to apply cc
+func consumerChanged(cur, cc *nats.ConsumerConfig) bool {
+	return cur.FilterSubject != cc.FilterSubject ||
+		cur.AckWait != cc.AckWait ||
+		cur.MaxDeliver != cc.MaxDeliver ||
+		cur.MaxAckPending != cc.MaxAckPending
+}
+
+// ensureConsumer creates the durable consumer, or recreates it from the first unacked record if changed
+func (h *streamHandler) ensureConsumer(js nats.JetStreamContext, cc *nats.ConsumerConfig) error {
+	info, err := js.ConsumerInfo(h.cfg.Stream, cc.Durable)
+	if err == nats.ErrConsumerNotFound {
+		_, err = js.AddConsumer(h.cfg.Stream, cc)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if !consumerChanged(&info.Config, cc) {
+		return nil
+	}
+	klog.Infof("(h) %s recreating consumer %s from sequence %d", h.trKey, cc.Durable, info.AckFloor.Stream+1)
+	cc.DeliverPolicy = nats.DeliverByStartSequencePolicy
+	cc.OptStartSeq = info.AckFloor.Stream + 1
+	if err := js.DeleteConsumer(h.cfg.Stream, cc.Durable); err != nil {
+		return err
+	}
+	_, err = js.AddConsumer(h.cfg.Stream, cc)
+	return err
+}
+
+func (h *streamHandler) start() error {
+	cc, err := consumerConfig(h.cfg)
+	if err != nil {
+		return err
+	}
+	js, err := client.GetNatsConn(h.operator.ctx).JetStream()
+	if err != nil {
+		return err
+	}
+	if err := h.ensureConsumer(js, cc); err != nil {
+		return err
+	}
+	// bind to the consumer, so that it will not be deleted by unsubscribing
+	h.sub, err = js.PullSubscribe(h.cfg.Subject, h.cfg.Durable, nats.Bind(h.cfg.Stream, h.cfg.Durable))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(h.operator.ctx)
+	h.cancel = cancel
+	for i := 0; i < h.cfg.MaxInflight; i++ {
+		h.wg.Add(1)
+		go func() {
+			defer h.wg.Done()
+			h.consume(ctx)
+		}()
+	}
+	return nil
+}
+
+// stop stops fetching, running invocations are not waited
+func (h *streamHandler) stop() {
+	if h.cancel != nil {
+		h.cancel()
+	}
+	if h.sub != nil {
+		if err := h.sub.Unsubscribe(); err != nil {
+			klog.Warningf("(h) %s failed to unsubscribe, %v", h.trKey, err)
+		}
+	}
+}
+
+// deleteConsumer removes the durable consumer once the trigger is deleted
+func (h *streamHandler) deleteConsumer() {
+	js, err := client.GetNatsConn(h.operator.ctx).JetStream()
+	if err == nil {
+		err = js.DeleteConsumer(h.cfg.Stream, h.cfg.Durable)
+	}
+	if err != nil && err != nats.ErrConsumerNotFound {
+		klog.Warningf("(h) %s failed to delete consumer %s, %v", h.trKey, h.cfg.Durable, err)
+	}
+}
+
+// consume fetches and handles batches until ctx is done
+func (h *streamHandler) consume(ctx context.Context) {
+	window := time.Duration(h.cfg.MaxBatchingWindowMs) * time.Millisecond
+	for ctx.Err() == nil {
+		msgs, err := h.sub.Fetch(h.cfg.BatchSize, nats.MaxWait(window))
+		if len(msgs) > 0 {
+			h.handleBatch(msgs)
+			continue
+		}
+		if err == nil || err == nats.ErrTimeout {
+			continue
+		}
+		klog.Errorf("(h) %s failed to fetch, %v", h.trKey, err)
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// handleBatch invokes func with msgs, the invocation is not canceled by stop
+func (h *streamHandler) handleBatch(msgs []*nats.Msg) {
+	event := StreamEvent{Records: make([]StreamRecord, 0, len(msgs))}
+	for _, msg := range msgs {
+		event.Records = append(event.Records, newStreamRecord(msg))
+	}
+
+	if !h.cfg.AckOnSuccess {
+		for _, msg := range msgs {
+			if err := msg.Ack(); err != nil {
+				klog.Warningf("(h) %s failed to ack %s, %v", h.trKey, msg.Subject, err)
+			}
+		}
+		if _, err := h.invoke(h.operator.ctx, &event); err != nil {
+			klog.Errorf("(h) %s failed to handle %d records, %v", h.trKey, len(msgs), err)
+		}
+		return
+	}
+
+	// prevent redelivery while the func is running
+	keepalive := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(time.Duration(h.cfg.AckWaitSeconds) * time.Second / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-keepalive:
+				return
+			case <-ticker.C:
+				for _, msg := range msgs {
+					msg.InProgress() // nolint:errcheck
+				}
+			}
+		}
+	}()
+	result, err := h.invoke(h.operator.ctx, &event)
+	close(keepalive)
+
+	var failed map[string]bool
+	if err != nil {
+		klog.Errorf("(h) %s failed to handle %d records, %v", h.trKey, len(msgs), err)
+	} else {
+		failed = failedRecords(result)
+	}
+	for i, msg := range msgs {
+		if err != nil || failed[event.Records[i].Sequence] {
+			// redelivered until MaxDeliver is reached
+			msg.Nak() // nolint:errcheck
+			continue
+		}
+		if err := msg.Ack(); err != nil {
+			klog.Warningf("(h) %s failed to ack %s, %v", h.trKey, msg.Subject, err)
+		}
+	}
+}
+
+func (h *streamHandler) invoke(ctx context.Context, event *StreamEvent) (result []byte, err error) {
+	defer func() {
+		if re := recover(); re != nil {
+			utils.LogTraceback(re, 5, klog.V(1))
+			err = fmt.Errorf("h: %v", re)
+		}
+	}()
+
+	trigger, err := h.operator.TriggerLister.Triggers(h.ns).Get(h.name)
+	if err != nil {
+		return nil, err
+	}
+	fndef, err := h.operator.ResolveFuncdef(trigger)
+	if err != nil {
+		return nil, err
+	}
+
+	var timeout = messages.DefaultJobTimeout
+	if fndef.Spec.Runtime != nil && fndef.Spec.Runtime.Timeout > 0 {
+		timeout = time.Second*time.Duration(fndef.Spec.Runtime.Timeout) + codeLaunchBias
+	}
+	ctx = client.WithLogger(ctx, klog.V(1))
+	ctx = client.WithTimeoutHint(ctx, timeout)
+	ctx = client.WithLoggingHint(ctx, false)
+
+	endpoint := fndef.Namespace + "/" + fndef.Name
+	taskr, err := client.NewTaskResolver(ctx, endpoint, &messages.InvokeRequest{
+		Args:      messages.MustFromObject(event),
+		RequestID: nuid.Next(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	<-taskr.Done()
+	return taskr.Result()
+}
@@ -0,0 +1,70 @@
+package streamtrigger
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	nats "github.com/nats-io/nats.go"
+)
+
+// StreamEvent is the args passed to funcs, a batch of records
+type StreamEvent struct {
+	Records []StreamRecord `json:"Records"`
+}
+
+// StreamRecord is a message of stream
+type StreamRecord struct {
+	EventSource string `json:"eventSource"`
+	Stream      string `json:"stream"`
+	Consumer    string `json:"consumer"`
+	Subject     string `json:"subject"`
+	// Sequence is the stream sequence of the message, it's the itemIdentifier of a record
+	Sequence      string              `json:"sequence"`
+	Timestamp     string              `json:"timestamp"`
+	DeliveryCount uint64              `json:"deliveryCount"`
+	Headers       map[string][]string `json:"headers,omitempty"`
+	// Data is base64 encoded
+	Data []byte `json:"data"`
+}
+
+// BatchResponse reports records failed in a batch, other records are treated as succeeded
+//
+//	{"batchItemFailures": [{"itemIdentifier": "42"}]}
+type BatchResponse struct {
+	BatchItemFailures []struct {
+		ItemIdentifier string `json:"itemIdentifier"`
+	} `json:"batchItemFailures"`
+}
+
+const eventSource = "refunc:stream"
+
+func newStreamRecord(msg *nats.Msg) StreamRecord {
+	record := StreamRecord{
+		EventSource: eventSource,
+		Subject:     msg.Subject,
+		Headers:     msg.Header,
+		Data:        msg.Data,
+	}
+	if meta, err := msg.Metadata(); err == nil {
+		record.Stream = meta.Stream
+		record.Consumer = meta.Consumer
+		record.Sequence = strconv.FormatUint(meta.Sequence.Stream, 10)
+		record.Timestamp = meta.Timestamp.UTC().Format(time.RFC3339Nano)
+		record.DeliveryCount = meta.NumDelivered
+	}
+	return record
+}
+
+// failedRecords returns sequences of failed records reported by result of a batch
+func failedRecords(result []byte) map[string]bool {
+	var rsp BatchResponse
+	if json.Unmarshal(result, &rsp) != nil || len(rsp.BatchItemFailures) == 0 {
+		return nil
+	}
+	failed := make(map[string]bool, len(rsp.BatchItemFailures))
+	for _, item := range rsp.BatchItemFailures {
+		failed[item.ItemIdentifier] = true
+	}
+	return failed
+}
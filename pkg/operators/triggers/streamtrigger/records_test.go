@@ -0,0 +1,90 @@
+package streamtrigger
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	nats "github.com/nats-io/nats.go"
+	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
+)
+
+func Test_newStreamRecord(t *testing.T) {
+	msg := &nats.Msg{
+		Subject: "orders.created",
+		Reply:   "$JS.ACK.orders.c1.2.42.7.1634000000000000000.3",
+		Data:    []byte("hello"),
+		Sub:     &nats.Subscription{},
+	}
+	record := newStreamRecord(msg)
+	if record.Stream != "orders" || record.Consumer != "c1" || record.Sequence != "42" || record.DeliveryCount != 2 {
+		t.Errorf("newStreamRecord() = %+v", record)
+	}
+
+	bts, _ := json.Marshal(StreamEvent{Records: []StreamRecord{record}})
+	var event map[string][]map[string]interface{}
+	if err := json.Unmarshal(bts, &event); err != nil {
+		t.Fatal(err)
+	}
+	if data := event["Records"][0]["data"]; data != "aGVsbG8=" {
+		t.Errorf("data = %v, want base64 encoded", data)
+	}
+}
+
+func Test_failedRecords(t *testing.T) {
+	tests := []struct {
+		result string
+		want   map[string]bool
+	}{
+		{`{"batchItemFailures":[{"itemIdentifier":"42"},{"itemIdentifier":"43"}]}`, map[string]bool{"42": true, "43": true}},
+		{`{"batchItemFailures":[]}`, nil},
+		{`"ok"`, nil},
+		{``, nil},
+	}
+	for _, tt := range tests {
+		if got := failedRecords([]byte(tt.result)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("failedRecords(%s) = %v, want %v", tt.result, got, tt.want)
+		}
+	}
+}
+
+func Test_consumerConfig(t *testing.T) {
+	trigger := &rfv1beta3.Trigger{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "orders.v1"},
+		Spec: rfv1beta3.TriggerSpec{TriggerConfig: rfv1beta3.TriggerConfig{
+			Stream: &rfv1beta3.StreamTrigger{Stream: "ns_orders", MaxInflight: 2, DeliverPolicy: rfv1beta3.StreamDeliverAll},
+		}},
+	}
+	cc, err := consumerConfig(streamConfig(trigger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cc.Durable != "ns_orders_v1" || cc.DeliverPolicy != nats.DeliverAllPolicy || cc.MaxDeliver != 5 || cc.MaxAckPending != 20 {
+		t.Errorf("consumerConfig() = %+v", cc)
+	}
+
+	trigger.Spec.Stream.DeliverPolicy = "first"
+	if _, err := consumerConfig(streamConfig(trigger)); err == nil {
+		t.Error("expect error of unknown deliver policy")
+	}
+}
+
+func Test_validateStream(t *testing.T) {
+	tests := []struct {
+		ns, stream string
+		wantErr    bool
+	}{
+		{"ns", "ns_orders", false},
+		{"ns", "orders", true},
+		{"ns", "other_orders", true},
+		{"ns", "nsx_orders", true},
+		{"ns", "ns", true},
+	}
+	for _, tt := range tests {
+		if err := validateStream(tt.ns, tt.stream); (err != nil) != tt.wantErr {
+			t.Errorf("validateStream(%q, %q) error = %v, wantErr %v", tt.ns, tt.stream, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package streamtrigger
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+
+	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
+	"github.com/refunc/refunc/pkg/client"
+	refunc "github.com/refunc/refunc/pkg/generated/clientset/versioned"
+	informers "github.com/refunc/refunc/pkg/generated/informers/externalversions"
+	operators "github.com/refunc/refunc/pkg/operators"
+	"github.com/refunc/refunc/pkg/utils"
+)
+
+// Operator consumes JetStream streams for stream triggers, and invokes funcs per batch
+type Operator struct {
+	*operators.BaseOperator
+
+	ctx context.Context
+
+	triggers sync.Map
+}
+
+// Type name for stream trigger
+const Type = "streamtrigger"
+
+// NewOperator creates a new stream trigger operator
+func NewOperator(
+	ctx context.Context,
+	cfg *rest.Config,
+	rclient refunc.Interface,
+	rfInformers informers.SharedInformerFactory,
+) (*Operator, error) {
+	base, err := operators.NewBaseOperator(cfg, rclient, rfInformers)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Operator{
+		BaseOperator: base,
+		ctx:          ctx,
+	}
+
+	return r, nil
+}
+
+// Run will not return until stopC is closed.
+func (r *Operator) Run(stopC <-chan struct{}) {
+	defer func() {
+		if re := recover(); re != nil {
+			utils.LogTraceback(re, 4, klog.V(1))
+		}
+	}()
+
+	if client.GetNatsConn(r.ctx) == nil {
+		klog.Error("(streamtrigger) nats connection is required")
+		return
+	}
+
+	r.RefuncInformers.Refunc().V1beta3().Triggers().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    r.handleTriggerAdd,
+		UpdateFunc: r.handleTriggerUpdate,
+		DeleteFunc: r.handleTriggerDelete,
+	})
+
+	klog.Info("(streamtrigger) starting stream trigger operator")
+
+	if !r.BaseOperator.WaitForCacheSync(stopC) {
+		klog.Error("(streamtrigger) cannot fully sync resources")
+		return
+	}
+
+	<-stopC
+
+	var handlers []*streamHandler
+	r.triggers.Range(func(k, v interface{}) bool {
+		h := v.(*streamHandler)
+		h.stop()
+		handlers = append(handlers, h)
+		return true
+	})
+	// wait running invocations to ack or nak their records
+	for _, h := range handlers {
+		h.wg.Wait()
+	}
+	klog.Info("(streamtrigger) shuting down stream trigger operator")
+}
+
+func (r *Operator) handleTriggerAdd(o interface{}) {
+	trigger := o.(*rfv1beta3.Trigger)
+	if trigger.Spec.Type != Type {
+		// skip other triggers
+		return
+	}
+
+	key := k8sKey(trigger)
+	if trigger.Spec.Stream == nil || trigger.Spec.Stream.Stream == "" {
+		klog.Errorf("(streamtrigger) %s stream is empty", key)
+		return
+	}
+	if err := validateStream(trigger.Namespace, trigger.Spec.Stream.Stream); err != nil {
+		klog.Errorf("(streamtrigger) %s %v", key, err)
+		return
+	}
+	if _, ok := r.triggers.Load(key); ok {
+		return
+	}
+
+	h := &streamHandler{
+		trKey:    key,
+		ns:       trigger.Namespace,
+		name:     trigger.Name,
+		cfg:      streamConfig(trigger),
+		operator: r,
+	}
+	if err := h.start(); err != nil {
+		klog.Errorf("(streamtrigger) %s failed to consume stream %s, %v", key, h.cfg.Stream, err)
+		return
+	}
+	r.triggers.Store(key, h)
+	klog.Infof("(streamtrigger) adding trigger %s, consuming %s by %s", key, h.cfg.Stream, h.cfg.Durable)
+}
+
+func (r *Operator) handleTriggerUpdate(oldObj, curObj interface{}) {
+	old := oldObj.(*rfv1beta3.Trigger)
+	cur := curObj.(*rfv1beta3.Trigger)
+
+	// Periodic resync may resend the deployment without changes in-between.
+	// Also breaks loops created by updating the resource ourselves.
+	if old.ResourceVersion == cur.ResourceVersion {
+		return
+	}
+	if old.Spec.Type != cur.Spec.Type || !reflect.DeepEqual(old.Spec.Stream, cur.Spec.Stream) {
+		key := k8sKey(cur)
+		if val, ok := r.triggers.LoadAndDelete(key); ok {
+			h := val.(*streamHandler)
+			h.stop()
+			if cur.Spec.Type != Type || cur.Spec.Stream == nil || streamConfig(cur).Durable != h.cfg.Durable || cur.Spec.Stream.Stream != h.cfg.Stream {
+				h.deleteConsumer()
+			}
+		}
+		// the consumer is kept and updated if possible
+		r.handleTriggerAdd(cur)
+	}
+}
+
+func (r *Operator) handleTriggerDelete(o interface{}) {
+	trigger, ok := o.(*rfv1beta3.Trigger)
+	if !ok {
+		// it's cache.DeletedFinalStateUnknown
+		return
+	}
+	if trigger.Spec.Type != Type {
+		// skip other triggers
+		return
+	}
+
+	key := k8sKey(trigger)
+	if val, ok := r.triggers.LoadAndDelete(key); ok {
+		klog.Infof("(streamtrigger) deleting trigger %s", key)
+		h := val.(*streamHandler)
+		h.stop()
+		h.deleteConsumer()
+	}
+}
+
+func k8sKey(o metav1.Object) string {
+	return o.GetNamespace() + "/" + o.GetName()
+}
@@ -11,7 +11,11 @@ import (
 type Interface interface {
 	TriggerForEndpoint(endpoint string) (*rfv1beta3.Trigger, error)
 	ResolveFuncdef(trigger *rfv1beta3.Trigger) (*rfv1beta3.Funcdef, error)
+	RouteFuncdef(trigger *rfv1beta3.Trigger) (*rfv1beta3.Funcdef, error)
+	ObserveFuncdef(trigger *rfv1beta3.Trigger, fndef *rfv1beta3.Funcdef, failed bool)
 	GetFuncInstance(trigger *rfv1beta3.Trigger) (*rfv1beta3.Funcinst, error)
+	WakeFuncInstance(fni *rfv1beta3.Funcinst) error
+	GetFuncMeta(trigger *rfv1beta3.Trigger) (*FuncMeta, error)
 	GetNamespace() string
 	Tap(key string)
 }
@@ -89,7 +89,7 @@ func (ld *simpleLoader) exec(fn *types.Function) error {
 		}
 	}
 
-	concurrency := withConcurrency(fn)
+	concurrency := WithConcurrency(fn)
 	cmds := []*exec.Cmd{}
 	for i := 0; i < concurrency; i++ {
 		cmd, err := ld.prepare(fn)
@@ -288,7 +288,9 @@ func withTmpFloder(fn func(dir string)) error {
 	return nil
 }
 
-func withConcurrency(fn *types.Function) int {
+// WithConcurrency returns the number of requests handled in parallel for fn,
+// which is capped by MaxLambdaConcurrency
+func WithConcurrency(fn *types.Function) int {
 	if fn.Annotations == nil {
 		return 1
 	}
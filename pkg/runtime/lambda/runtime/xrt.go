@@ -375,6 +375,7 @@ func (rt *lambda) genFunction(pod *corev1.Pod, fninst *rfv1beta3.Funcinst, fcdef
 	// nats endpoints
 	fn.Spec.Runtime.Envs["REFUNC_CRY_ENDPOINT"] = fninst.CryingEndpoint()
 	fn.Spec.Runtime.Envs["REFUNC_TAP_ENDPOINT"] = fninst.TappingEndpoint()
+	fn.Spec.Runtime.Envs["REFUNC_METRICS_ENDPOINT"] = fninst.MetricsEndpoint()
 	fn.Spec.Runtime.Envs["REFUNC_LOG_ENDPOINT"] = fninst.LoggingEndpoint()
 	fn.Spec.Runtime.Envs["REFUNC_SVC_ENDPOINT"] = fninst.ServiceEndpoint()
 	fn.Spec.Runtime.Envs["REFUNC_CRY_SVC_ENDPOINT"] = fninst.CryServiceEndpoint()
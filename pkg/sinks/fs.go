@@ -0,0 +1,62 @@
+package sinks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// EnvFSSinkRoot is the env of root directory of fs sinks
+const EnvFSSinkRoot = "REFUNC_FS_SINK_ROOT"
+
+// fsSink saves outputs to local files, using the layout
+//
+//	<root>/<ns>/<config>/<trigger>/logs/<time>_<id>[.seq].log
+//	<root>/<ns>/<config>/<trigger>/results/<time>_<id>.json
+//	<root>/<ns>/<config>/<trigger>/deadletters/<time>_<id>.json
+type fsSink struct {
+	root string
+	sub  string
+}
+
+func (s fsSink) write(run *Run, kind, name string, data []byte) (string, error) {
+	dir := filepath.Join(s.root, run.Namespace, s.sub, run.Trigger, kind)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, name)
+	return path, ioutil.WriteFile(path, data, 0644)
+}
+
+func (s fsSink) WriteLogs(run *Run, seq int, lines []byte) (string, error) {
+	suffix := ""
+	if seq > 0 {
+		suffix = fmt.Sprintf(".%d", seq)
+	}
+	return s.write(run, "logs", fmt.Sprintf("%s%s.log", run.Name(), suffix), lines)
+}
+
+func (s fsSink) WriteResult(run *Run, result []byte) (string, error) {
+	return s.write(run, "results", fmt.Sprintf("%s.json", run.Name()), result)
+}
+
+func (s fsSink) WriteDeadLetter(run *Run, letter []byte) (string, error) {
+	return s.write(run, "deadletters", fmt.Sprintf("%s.json", run.Name()), letter)
+}
+
+// CreateFSSink creates a sink to sub directory cfg of namespaces under REFUNC_FS_SINK_ROOT
+func CreateFSSink(ctx context.Context, cfg string) (Sink, error) {
+	root := os.Getenv(EnvFSSinkRoot)
+	if root == "" {
+		return nil, errors.New("sinks: fs sink is not enabled, " + EnvFSSinkRoot + " is not set")
+	}
+	// sub directory never escapes from root
+	return fsSink{root: root, sub: filepath.Clean("/" + cfg)}, nil
+}
+
+func init() {
+	Register("fs", CreateFSSink)
+}
@@ -0,0 +1,48 @@
+package sinks
+
+import (
+	"context"
+	"errors"
+
+	"github.com/refunc/refunc/pkg/client"
+	"github.com/refunc/refunc/pkg/messages"
+)
+
+// funcSink invokes a function in the same namespace with Records of results
+// and dead letters, logs are dropped.
+type funcSink struct {
+	ctx      context.Context
+	funcName string
+}
+
+func (s funcSink) WriteLogs(run *Run, seq int, lines []byte) (string, error) {
+	return "", nil
+}
+
+func (s funcSink) WriteResult(run *Run, result []byte) (string, error) {
+	return s.invoke(&Record{Run: run, Result: toRawMessage(result)})
+}
+
+func (s funcSink) WriteDeadLetter(run *Run, letter []byte) (string, error) {
+	return s.invoke(&Record{Run: run, DeadLetter: letter})
+}
+
+func (s funcSink) invoke(record *Record) (string, error) {
+	ctx, cancel := context.WithTimeout(s.ctx, messages.DefaultJobTimeout)
+	defer cancel()
+	endpoint := record.Namespace + "/" + s.funcName
+	_, err := client.Invoke(ctx, endpoint, record)
+	return endpoint, err
+}
+
+// CreateFuncSink creates a sink invoking function cfg
+func CreateFuncSink(ctx context.Context, cfg string) (Sink, error) {
+	if cfg == "" {
+		return nil, errors.New("sinks: name of function is required by func sink")
+	}
+	return funcSink{ctx: ctx, funcName: cfg}, nil
+}
+
+func init() {
+	Register("func", CreateFuncSink)
+}
@@ -0,0 +1,55 @@
+package sinks
+
+import (
+	"context"
+	"errors"
+
+	nats "github.com/nats-io/nats.go"
+	"github.com/refunc/refunc/pkg/client"
+	"github.com/refunc/refunc/pkg/messages"
+)
+
+// natsSink publishes outputs as Records to refunc.sinks.<ns>.<config>,
+// the config defaults to name of the trigger.
+type natsSink struct {
+	conn    *nats.Conn
+	subject string
+}
+
+func (s natsSink) subjectOf(run *Run) string {
+	subject := s.subject
+	if subject == "" {
+		subject = run.Trigger
+	}
+	return "refunc.sinks." + run.Namespace + "." + subject
+}
+
+func (s natsSink) publish(run *Run, record *Record) (string, error) {
+	subject := s.subjectOf(run)
+	return subject, s.conn.Publish(subject, messages.MustFromObject(record))
+}
+
+func (s natsSink) WriteLogs(run *Run, seq int, lines []byte) (string, error) {
+	return s.publish(run, &Record{Run: run, Seq: seq, Logs: string(lines)})
+}
+
+func (s natsSink) WriteResult(run *Run, result []byte) (string, error) {
+	return s.publish(run, &Record{Run: run, Result: toRawMessage(result)})
+}
+
+func (s natsSink) WriteDeadLetter(run *Run, letter []byte) (string, error) {
+	return s.publish(run, &Record{Run: run, DeadLetter: letter})
+}
+
+// CreateNatsSink creates a sink publishing to subject cfg
+func CreateNatsSink(ctx context.Context, cfg string) (Sink, error) {
+	conn := client.GetNatsConn(ctx)
+	if conn == nil {
+		return nil, errors.New("sinks: nats is not connected")
+	}
+	return natsSink{conn: conn, subject: cfg}, nil
+}
+
+func init() {
+	Register("nats", CreateNatsSink)
+}
@@ -0,0 +1,59 @@
+package sinks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+
+	minio "github.com/minio/minio-go"
+	"github.com/refunc/refunc/pkg/env"
+)
+
+// s3Sink saves outputs to the global bucket, using the layout
+//
+//	<ns>/_system/<type>s/<trigger>/logs/<time>_<id>[.seq].log
+//	<ns>/_system/<type>s/<trigger>/results/<time>_<id>.json
+//	<ns>/_system/<type>s/<trigger>/deadletters/<time>_<id>.json
+type s3Sink struct{}
+
+func (s3Sink) key(run *Run, kind, name string) string {
+	return env.KeyWithinScope(filepath.Join(run.Namespace, "_system", run.Type+"s", run.Trigger, kind, name))
+}
+
+func (s s3Sink) WriteLogs(run *Run, seq int, lines []byte) (string, error) {
+	suffix := ""
+	if seq > 0 {
+		suffix = fmt.Sprintf(".%d", seq)
+	}
+	key := s.key(run, "logs", fmt.Sprintf("%s%s.log", run.Name(), suffix))
+	return key, putObject(key, lines, "text/plain; charset=UTF-8")
+}
+
+func (s s3Sink) WriteResult(run *Run, result []byte) (string, error) {
+	key := s.key(run, "results", fmt.Sprintf("%s.json", run.Name()))
+	return key, putObject(key, result, "text/plain; charset=UTF-8")
+}
+
+func (s s3Sink) WriteDeadLetter(run *Run, letter []byte) (string, error) {
+	key := s.key(run, "deadletters", fmt.Sprintf("%s.json", run.Name()))
+	return key, putObject(key, letter, "application/json")
+}
+
+func putObject(key string, data []byte, contentType string) error {
+	_, err := env.GlobalMinioClient().PutObject(
+		env.GlobalBucket, key,
+		bytes.NewReader(data), int64(len(data)),
+		minio.PutObjectOptions{ContentType: contentType},
+	)
+	return err
+}
+
+// CreateS3Sink creates a sink to the object store
+func CreateS3Sink(ctx context.Context, cfg string) (Sink, error) {
+	return s3Sink{}, nil
+}
+
+func init() {
+	Register("s3", CreateS3Sink)
+}
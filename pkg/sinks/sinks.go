@@ -0,0 +1,101 @@
+package sinks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
+	"github.com/refunc/refunc/pkg/messages"
+)
+
+// Run identifies an execution of a trigger whose outputs are sinked
+type Run struct {
+	Namespace string `json:"namespace"`
+	// Type of the trigger, like crontrigger
+	Type     string `json:"type"`
+	Trigger  string `json:"trigger"`
+	FuncName string `json:"funcName"`
+	ID       string `json:"id"`
+	// Time is the RFC3339 formated time of the run
+	Time string `json:"time"`
+}
+
+// Name returns the name of outputs of the run, runs of a trigger started
+// at the same second are told apart by ID
+func (r *Run) Name() string {
+	if r.ID == "" {
+		return r.Time
+	}
+	return r.Time + "_" + r.ID
+}
+
+// Sink saves logs and results of trigger runs
+type Sink interface {
+	// WriteLogs saves the seq-th chunk of logs, returns the location of the chunk
+	WriteLogs(run *Run, seq int, lines []byte) (string, error)
+	// WriteResult saves the result, returns the location of the result
+	WriteResult(run *Run, result []byte) (string, error)
+	// WriteDeadLetter saves the document of a run failed after all attempts,
+	// returns the location of the document
+	WriteDeadLetter(run *Run, letter []byte) (string, error)
+}
+
+// Creator creates a sink using the config of trigger
+type Creator func(ctx context.Context, cfg string) (Sink, error)
+
+// DefaultSink is the name of sink used if not specified
+const DefaultSink = "s3"
+
+var registry struct {
+	sync.Mutex
+	creators map[string]Creator
+}
+
+// Register adds a sink creator to registry
+func Register(name string, creator Creator) {
+	registry.Lock()
+	defer registry.Unlock()
+	if registry.creators == nil {
+		registry.creators = make(map[string]Creator)
+	}
+	registry.creators[name] = creator
+}
+
+// CreateSink returns the sink selected by a trigger
+func CreateSink(ctx context.Context, sink *rfv1beta3.TriggerSink) (Sink, error) {
+	name, cfg := DefaultSink, ""
+	if sink != nil {
+		if sink.Name != "" {
+			name = sink.Name
+		}
+		cfg = sink.Config
+	}
+
+	registry.Lock()
+	creator, ok := registry.creators[name]
+	registry.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("sinks: invalid sink %q", name)
+	}
+	return creator(ctx, cfg)
+}
+
+// Record is the message published by nats sink, and the args of function invoked by func sink
+type Record struct {
+	*Run
+	Seq    int             `json:"seq,omitempty"`
+	Logs   string          `json:"logs,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+
+	DeadLetter json.RawMessage `json:"deadLetter,omitempty"`
+}
+
+// toRawMessage returns result as is if it's a valid json, otherwise as a json string
+func toRawMessage(result []byte) json.RawMessage {
+	if json.Valid(result) {
+		return result
+	}
+	return messages.MustFromObject(string(result))
+}
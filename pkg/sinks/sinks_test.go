@@ -0,0 +1,47 @@
+package sinks
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
+)
+
+func TestCreateSink_fs(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv(EnvFSSinkRoot, root)
+
+	sink, err := CreateSink(context.Background(), &rfv1beta3.TriggerSink{Name: "fs", Config: "../../outputs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := &Run{Namespace: "ns", Type: "crontrigger", Trigger: "nightly", ID: "r1", Time: "2026-01-01T00:00:00Z"}
+
+	tests := []struct {
+		write func() (string, error)
+		want  string
+	}{
+		{func() (string, error) { return sink.WriteLogs(run, 0, []byte("a")) }, "ns/outputs/nightly/logs/2026-01-01T00:00:00Z_r1.log"},
+		{func() (string, error) { return sink.WriteLogs(run, 1, []byte("b")) }, "ns/outputs/nightly/logs/2026-01-01T00:00:00Z_r1.1.log"},
+		{func() (string, error) { return sink.WriteResult(run, []byte("{}")) }, "ns/outputs/nightly/results/2026-01-01T00:00:00Z_r1.json"},
+		{func() (string, error) { return sink.WriteDeadLetter(run, []byte("{}")) }, "ns/outputs/nightly/deadletters/2026-01-01T00:00:00Z_r1.json"},
+	}
+	for _, tt := range tests {
+		path, err := tt.write()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := filepath.Join(root, tt.want); path != want {
+			t.Errorf("path = %q, want %q", path, want)
+		}
+		if _, err := os.Stat(path); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if _, err := CreateSink(context.Background(), &rfv1beta3.TriggerSink{Name: "unknown"}); err == nil {
+		t.Error("CreateSink() want error for unknown sink")
+	}
+}
@@ -116,9 +116,12 @@ func (nh *natsHandler) onRequest(msg *nats.Msg) {
 	// dispatch messages
 	switch path {
 	case "_meta":
-		// TODO (bin): maybe using annotations
-		nh.replyMeta(msg.Reply, fndef)
+		nh.replyMeta(msg.Reply, fndef, trigger)
 	default:
+		if trigger.Spec.Traffic != nil {
+			go nh.forwardSplitRequest(msg, trigger)
+			return
+		}
 		go nh.forwardRequest(msg, fndef, trigger)
 	}
 }
@@ -142,11 +145,16 @@ func (nh *natsHandler) onTap(msg *nats.Msg) {
 	nh.operator.Tap(subject)
 }
 
-func (nh *natsHandler) replyMeta(to string, fndef *rfv1beta3.Funcdef) {
-	if err := nh.natsConn.Publish(to, []byte{'{', '}'}); err != nil {
-		nh.replyError(fmt.Sprintf("refunc.%s.%s._meta", fndef.Namespace, fndef.Name), to, err)
+func (nh *natsHandler) replyMeta(to string, fndef *rfv1beta3.Funcdef, trigger *rfv1beta3.Trigger) {
+	subject := fmt.Sprintf("refunc.%s.%s._meta", fndef.Namespace, fndef.Name)
+	meta, err := nh.operator.GetFuncMeta(trigger)
+	if err != nil {
+		nh.replyError(subject, to, err)
+		return
+	}
+	if err := nh.natsConn.Publish(to, messages.MustFromObject(meta)); err != nil {
+		nh.replyError(subject, to, err)
 	}
-	return
 }
 
 func (nh *natsHandler) forwardRequest(msg *nats.Msg, fndef *rfv1beta3.Funcdef, trigger *rfv1beta3.Trigger) {
@@ -222,8 +230,54 @@ func (nh *natsHandler) forwardRequest(msg *nats.Msg, fndef *rfv1beta3.Funcdef, t
 
 	klog.V(3).Infof("(nh) forward request when %q is online", fninst.Name)
 
+	pr := newPendingRequest()
+	q, created, err := nh.enqueuePending(cryEndpoint, pr)
+	if err != nil {
+		nh.replyError(msg.Subject, msg.Reply, err)
+		return
+	}
+	if created {
+		go nh.waitOnline(q, fninst, trigger)
+	}
+
+	if nh.cfg.MaxPendingWait > 0 {
+		var cancelWait context.CancelFunc
+		ctx, cancelWait = context.WithTimeout(ctx, nh.cfg.MaxPendingWait)
+		defer cancelWait()
+	}
+	select {
+	case <-ctx.Done():
+		q.remove(pr)
+		nh.replyError(msg.Subject, msg.Reply, ctx.Err())
+	case err := <-pr.done:
+		if err != nil {
+			nh.replyError(msg.Subject, msg.Reply, err)
+			return
+		}
+		forwardReq()
+	}
+}
+
+// waitOnline wakes funcinst if it's scaled to zero, and drains pending requests once it's online
+func (nh *natsHandler) waitOnline(q *pendingQueue, fninst *rfv1beta3.Funcinst, trigger *rfv1beta3.Trigger) {
+	defer func() {
+		if re := recover(); re != nil {
+			utils.LogTraceback(re, 5, klog.V(1))
+			nh.drain(q, fmt.Errorf("nh: %v", re))
+		}
+	}()
+
+	if fninst.Status.IsScaledToZero() {
+		if err := nh.operator.WakeFuncInstance(fninst); err != nil {
+			klog.Errorf("(nh) failed to wake %q, %v", fninst.Name, err)
+			nh.drain(q, err)
+			return
+		}
+	}
+
+	cryEndpoint := fninst.CryingEndpoint()
 	cryReqEp := fninst.CryServiceEndpoint()
-	val, _ := nh.cryMap.LoadOrStore(cryEndpoint, nh.newCrySig(cryEndpoint, func() { conn.Publish(cryReqEp, nil) }))
+	val, _ := nh.cryMap.LoadOrStore(cryEndpoint, nh.newCrySig(cryEndpoint, func() { nh.natsConn.Publish(cryReqEp, nil) }))
 	onlineC, sig := val.(crySigFn)()
 	defer sig(false) // ensure no resource leak
 
@@ -231,16 +285,21 @@ func (nh *natsHandler) forwardRequest(msg *nats.Msg, fndef *rfv1beta3.Funcdef, t
 	defer ticker.Stop()
 	for {
 		select {
-		case <-ctx.Done():
-			nh.replyError(msg.Subject, msg.Reply, ctx.Err())
+		case <-nh.ctx.Done():
+			nh.drain(q, nh.ctx.Err())
 			return
 		case <-onlineC:
-			forwardReq()
+			n := nh.drain(q, nil)
+			klog.V(3).Infof("(nh) %q is online, forwarding #%d pending requests", fninst.Name, n)
 			return
 		case <-ticker.C:
-			fninst, err = nh.operator.GetFuncInstance(trigger)
+			if nh.closeIfEmpty(q) {
+				// all requests are timed out
+				return
+			}
+			fninst, err := nh.operator.GetFuncInstance(trigger)
 			if err != nil {
-				nh.replyError(msg.Subject, msg.Reply, err)
+				nh.drain(q, err)
 				return
 			}
 			// check if something goes wrong
@@ -248,19 +307,19 @@ func (nh *natsHandler) forwardRequest(msg *nats.Msg, fndef *rfv1beta3.Funcdef, t
 				switch cond.Type {
 				case rfv1beta3.FuncinstInactive:
 					if fninst.Status.IsInactiveCondition() {
-						nh.replyError(msg.Subject, msg.Reply, errors.New(cond.Message))
+						nh.drain(q, errors.New(cond.Message))
 						return
 					}
 				case rfv1beta3.FuncinstPending:
 					if cond.Status == corev1.ConditionTrue && cond.Reason == "XenvNotResolved" {
-						nh.replyError(msg.Subject, msg.Reply, errors.New(cond.Message))
+						nh.drain(q, errors.New(cond.Message))
 						return
 					}
 				case rfv1beta3.FuncinstActive:
 					if cond.Status == corev1.ConditionFalse {
 						switch cond.Reason {
 						case "ReplicasetNotReady":
-							nh.replyError(msg.Subject, msg.Reply, errors.New(cond.Message))
+							nh.drain(q, errors.New(cond.Message))
 							return
 						}
 					}
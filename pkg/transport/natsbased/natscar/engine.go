@@ -14,6 +14,7 @@ import (
 	observer "github.com/refunc/go-observer"
 	"github.com/refunc/refunc/pkg/env"
 	"github.com/refunc/refunc/pkg/messages"
+	"github.com/refunc/refunc/pkg/runtime/lambda/loader"
 	"github.com/refunc/refunc/pkg/runtime/types"
 	"github.com/refunc/refunc/pkg/sidecar"
 	"github.com/refunc/refunc/pkg/utils"
@@ -56,6 +57,7 @@ func (eng *engine) Init(ctx context.Context, fn *types.Function) error {
 		cryEndpoint    = fn.Spec.Runtime.Envs["REFUNC_CRY_ENDPOINT"]
 		svcEndpoint    = fn.Spec.Runtime.Envs["REFUNC_SVC_ENDPOINT"]
 		tapEndpoint    = fn.Spec.Runtime.Envs["REFUNC_TAP_ENDPOINT"]
+		metEndpoint    = fn.Spec.Runtime.Envs["REFUNC_METRICS_ENDPOINT"]
 		crysvcEndpoint = fn.Spec.Runtime.Envs["REFUNC_CRY_SVC_ENDPOINT"]
 	)
 
@@ -192,6 +194,8 @@ func (eng *engine) Init(ctx context.Context, fn *types.Function) error {
 		defer crySubs.Unsubscribe() //nolint:errcheck
 
 		klog.V(2).Infof("(natscar) %s started", fn.Name)
+		metrics := messages.InflightMetrics{Concurrency: loader.WithConcurrency(fn)}
+		metrics.Pod, _ = os.Hostname()
 		tapTicker := time.NewTicker(2 * time.Second)
 		defer tapTicker.Stop()
 		for {
@@ -200,14 +204,19 @@ func (eng *engine) Init(ctx context.Context, fn *types.Function) error {
 				// wait until we are requested to leave
 				return
 			case <-tapTicker.C:
-				var hasTasks bool
+				var inflight int
 				eng.sessions.Range(func(key, value interface{}) bool {
-					hasTasks = true
-					return false
+					inflight++
+					return true
 				})
-				if hasTasks {
+				if inflight > 0 {
 					eng.publish(tapEndpoint, nil)
 				}
+				// report even if idle, so that the controller can scale down
+				if metEndpoint != "" {
+					metrics.Inflight = inflight
+					eng.publish(metEndpoint, messages.MustFromObject(&metrics))
+				}
 			}
 		}
 	}()
@@ -0,0 +1,86 @@
+package natsbased
+
+import (
+	"errors"
+	"sync"
+)
+
+var errTooManyPending = errors.New("Too many requests waiting for funcinst to be online")
+
+// pendingRequest waits in a pendingQueue, done receives nil when funcinst is online
+type pendingRequest struct {
+	done chan error
+}
+
+func newPendingRequest() *pendingRequest {
+	return &pendingRequest{done: make(chan error, 1)}
+}
+
+// pendingQueue buffers requests of a funcinst until it's online
+type pendingQueue struct {
+	sync.Mutex
+
+	key    string
+	reqs   []*pendingRequest
+	closed bool
+}
+
+// enqueuePending adds pr to the queue of funcinst, created is true if pr is the first one
+func (nh *natsHandler) enqueuePending(key string, pr *pendingRequest) (q *pendingQueue, created bool, err error) {
+	for {
+		val, loaded := nh.pendings.LoadOrStore(key, &pendingQueue{key: key})
+		q = val.(*pendingQueue)
+
+		q.Lock()
+		if q.closed {
+			// queue is drained right now, it's removed from pendings, try again
+			q.Unlock()
+			continue
+		}
+		if len(q.reqs) >= nh.cfg.MaxPending {
+			q.Unlock()
+			return nil, false, errTooManyPending
+		}
+		q.reqs = append(q.reqs, pr)
+		q.Unlock()
+		return q, !loaded, nil
+	}
+}
+
+// drain releases all requests in the queue with err, and closes the queue
+func (nh *natsHandler) drain(q *pendingQueue, err error) int {
+	q.Lock()
+	reqs := q.reqs
+	q.reqs, q.closed = nil, true
+	nh.pendings.CompareAndDelete(q.key, q)
+	q.Unlock()
+
+	for _, pr := range reqs {
+		pr.done <- err
+	}
+	return len(reqs)
+}
+
+// remove removes a request given up waiting
+func (q *pendingQueue) remove(pr *pendingRequest) {
+	q.Lock()
+	defer q.Unlock()
+	for i := range q.reqs {
+		if q.reqs[i] == pr {
+			q.reqs = append(q.reqs[:i], q.reqs[i+1:]...)
+			return
+		}
+	}
+}
+
+// closeIfEmpty closes the queue if no request is waiting
+func (nh *natsHandler) closeIfEmpty(q *pendingQueue) bool {
+	q.Lock()
+	defer q.Unlock()
+	if len(q.reqs) > 0 {
+		return false
+	}
+	q.closed = true
+	nh.pendings.CompareAndDelete(q.key, q)
+	return true
+}
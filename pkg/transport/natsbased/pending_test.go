@@ -0,0 +1,41 @@
+package natsbased
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPendingQueue(t *testing.T) {
+	nh := NewHandler(nil, Config{MaxPending: 2}).(*natsHandler)
+
+	pr1, pr2 := newPendingRequest(), newPendingRequest()
+	q, created, err := nh.enqueuePending("ns/fni", pr1)
+	if err != nil || !created {
+		t.Fatalf("first request should create queue, %v", err)
+	}
+	if _, created, err := nh.enqueuePending("ns/fni", pr2); err != nil || created {
+		t.Fatalf("second request should join queue, %v", err)
+	}
+	if _, _, err := nh.enqueuePending("ns/fni", newPendingRequest()); err != errTooManyPending {
+		t.Fatalf("queue should be bounded, got %v", err)
+	}
+
+	q.remove(pr2)
+	if nh.closeIfEmpty(q) {
+		t.Fatal("queue with pending requests should not be closed")
+	}
+
+	errOffline := errors.New("offline")
+	if n := nh.drain(q, errOffline); n != 1 {
+		t.Fatalf("drained %d requests, want 1", n)
+	}
+	if err := <-pr1.done; err != errOffline {
+		t.Errorf("got %v, want %v", err, errOffline)
+	}
+
+	// a new queue is created after draining
+	q2, created, err := nh.enqueuePending("ns/fni", newPendingRequest())
+	if err != nil || !created || q2 == q {
+		t.Fatalf("request after draining should create a new queue, %v", err)
+	}
+}
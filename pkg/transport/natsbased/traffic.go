@@ -0,0 +1,106 @@
+package natsbased
+
+import (
+	"encoding/json"
+	"time"
+
+	"k8s.io/klog"
+
+	nats "github.com/nats-io/nats.go"
+	rfv1beta3 "github.com/refunc/refunc/pkg/apis/refunc/v1beta3"
+	"github.com/refunc/refunc/pkg/messages"
+	"github.com/refunc/refunc/pkg/utils"
+)
+
+// forwardSplitRequest forwards a request to the version of function picked by trigger's traffic split,
+// results of the canary are relayed to the requester and observed to roll it back if it fails too often.
+func (nh *natsHandler) forwardSplitRequest(msg *nats.Msg, trigger *rfv1beta3.Trigger) {
+	defer func() {
+		if re := recover(); re != nil {
+			utils.LogTraceback(re, 5, klog.V(1))
+		}
+	}()
+
+	fndef, err := nh.operator.RouteFuncdef(trigger)
+	if err != nil {
+		nh.replyError(msg.Subject, msg.Reply, err)
+		return
+	}
+	if !trigger.IsCanary(fndef) {
+		nh.forwardRequest(msg, fndef, trigger)
+		return
+	}
+
+	// funcinsts of canary are created for its own trigger
+	canary, err := nh.operator.TriggerForEndpoint(fndef.Namespace + "/" + fndef.Name)
+	if err != nil {
+		klog.V(3).Infof("(nh) canary %q of %s/%s is not served, %v", fndef.Name, trigger.Namespace, trigger.Name, err)
+		nh.operator.ObserveFuncdef(trigger, fndef, true)
+		// fallback to the stable version
+		if fndef, err = nh.operator.ResolveFuncdef(trigger); err != nil {
+			nh.replyError(msg.Subject, msg.Reply, err)
+			return
+		}
+		nh.forwardRequest(msg, fndef, trigger)
+		return
+	}
+	klog.V(4).Infof("(nh) routing request of %s/%s to canary %q", trigger.Namespace, trigger.Name, fndef.Name)
+	if trigger.Spec.Traffic.Rollback == nil {
+		nh.forwardRequest(msg, fndef, canary)
+		return
+	}
+
+	failedC := make(chan bool, 1)
+	inbox := nats.NewInbox()
+	sub, err := nh.natsConn.Subscribe(inbox, func(reply *nats.Msg) {
+		if err := nh.natsConn.Publish(msg.Reply, reply.Data); err != nil {
+			klog.Errorf("(nh) failed to relay reply to %q, %v", msg.Reply, err)
+		}
+		if failed, done := isFailedReply(reply.Data); done {
+			select {
+			case failedC <- failed:
+			default:
+			}
+		}
+	})
+	if err != nil {
+		nh.operator.ObserveFuncdef(trigger, fndef, true)
+		nh.replyError(msg.Subject, msg.Reply, err)
+		return
+	}
+	defer sub.Unsubscribe() // nolint:errcheck
+
+	nh.forwardRequest(&nats.Msg{Subject: msg.Subject, Reply: inbox, Data: msg.Data}, fndef, canary)
+
+	var timeout = messages.DefaultJobTimeout
+	if fndef.Spec.Runtime != nil && fndef.Spec.Runtime.Timeout > 0 {
+		timeout = time.Second*time.Duration(fndef.Spec.Runtime.Timeout) + codeLaunchBias
+	}
+	select {
+	case <-nh.ctx.Done():
+	case failed := <-failedC:
+		nh.operator.ObserveFuncdef(trigger, fndef, failed)
+	case <-time.After(timeout):
+		klog.V(3).Infof("(nh) canary %q of %s/%s timed out", fndef.Name, trigger.Namespace, trigger.Name)
+		nh.operator.ObserveFuncdef(trigger, fndef, true)
+	}
+}
+
+// isFailedReply parses a reply of invocation, done is true if it's the last one
+func isFailedReply(data []byte) (failed, done bool) {
+	var action messages.Action
+	if err := json.Unmarshal(data, &action); err != nil {
+		return false, false
+	}
+	switch action.Type {
+	case messages.Error:
+		return true, true
+	case messages.Response:
+		var rsp messages.InvokeResponse
+		if err := json.Unmarshal(action.Payload, &rsp); err != nil {
+			return true, true
+		}
+		return rsp.Error != nil, true
+	}
+	return false, false
+}
@@ -3,6 +3,7 @@ package natsbased
 import (
 	"context"
 	"sync"
+	"time"
 
 	nats "github.com/nats-io/nats.go"
 	"github.com/refunc/refunc/pkg/operators"
@@ -18,12 +19,32 @@ type natsHandler struct {
 	cancel context.CancelFunc
 
 	cryMap sync.Map
+
+	cfg Config
+	// pending requests of funcinsts which are not online
+	pendings sync.Map
+}
+
+// Config of nats based handler
+type Config struct {
+	// MaxPending is the max number of requests waiting for a funcinst to be online
+	MaxPending int
+	// MaxPendingWait is the max duration a request waits for a funcinst to be online,
+	// 0 means waiting until timeout of the request
+	MaxPendingWait time.Duration
 }
 
+// DefaultMaxPending is the default max number of pending requests of a funcinst
+const DefaultMaxPending = 1024
+
 // NewHandler creates a nats based transport.OperatorHandler
-func NewHandler(conn *nats.Conn) transport.OperatorHandler {
+func NewHandler(conn *nats.Conn, cfg Config) transport.OperatorHandler {
+	if cfg.MaxPending <= 0 {
+		cfg.MaxPending = DefaultMaxPending
+	}
 	return &natsHandler{
 		natsConn: conn,
+		cfg:      cfg,
 	}
 }
 
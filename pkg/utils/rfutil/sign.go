@@ -3,6 +3,7 @@ package rfutil
 import (
 	"crypto/ecdsa"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"sync"
@@ -13,9 +14,12 @@ import (
 // well known errros
 var (
 	ErrMissingPrivateKeyFile = errors.New(`creds: "ECDSA_KEY_FILE" not specified`)
+	ErrMissingPublicKeyFile  = errors.New(`creds: "ECDSA_PUBLICKEY_FILE" not specified`)
 
 	// private ECDSA key to sign token
 	privateECDSAKeyFile string
+	// public ECDSA key to verify token
+	publicECDSAKeyFile string
 )
 
 // Sign signs given claims with ES256
@@ -29,12 +33,31 @@ func Sign(claims jwt.Claims) (string, error) {
 	return token.SignedString(key)
 }
 
+// Verify parses and validates a token signed by Sign, returns its claims
+func Verify(tokenString string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodECDSA); !ok {
+			return nil, fmt.Errorf("expect token signed with ECDSA but got %v", t.Header["alg"])
+		}
+		return getPublicKey()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
 var (
 	// TODO: make sign alg configurable
 	signAlg     = jwt.GetSigningMethod("ES256")
 	ecdsaKey    *ecdsa.PrivateKey
 	loadKeyErr  error
 	loadKeyOnce sync.Once
+
+	ecdsaPubKey    *ecdsa.PublicKey
+	loadPubKeyErr  error
+	loadPubKeyOnce sync.Once
 )
 
 func getPrivateKey() (*ecdsa.PrivateKey, error) {
@@ -59,6 +82,29 @@ func getPrivateKey() (*ecdsa.PrivateKey, error) {
 	return ecdsaKey, loadKeyErr
 }
 
+func getPublicKey() (*ecdsa.PublicKey, error) {
+	loadPubKeyOnce.Do(func() {
+		if publicECDSAKeyFile == "" {
+			loadPubKeyErr = ErrMissingPublicKeyFile
+			return
+		}
+		f, err := os.Open(publicECDSAKeyFile)
+		if err != nil {
+			loadPubKeyErr = err
+			return
+		}
+		defer f.Close()
+		keyBytes, err := ioutil.ReadAll(f)
+		if err != nil {
+			loadPubKeyErr = err
+			return
+		}
+		ecdsaPubKey, loadPubKeyErr = jwt.ParseECPublicKeyFromPEM(keyBytes)
+	})
+	return ecdsaPubKey, loadPubKeyErr
+}
+
 func init() {
 	privateECDSAKeyFile = os.Getenv("ECDSA_PRIVATEKEY_FILE")
+	publicECDSAKeyFile = os.Getenv("ECDSA_PUBLICKEY_FILE")
 }
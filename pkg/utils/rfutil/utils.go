@@ -103,6 +103,9 @@ func GetSpecHash(fndef *rfv1beta3.Funcdef) string {
 	annotations := fn.Annotations
 	spec := fn.Spec
 	spec.Hash = ""
+	// autoscaling is reconciled without recreating funcinsts
+	spec.Autoscaling = nil
+	spec.ProvisionedConcurrency = 0
 	return GetMD5Hash(map[string]interface{}{
 		"spec":        spec,
 		"annotations": annotations,
@@ -148,7 +151,7 @@ func UpdateFuncinstStatus(c rfcliv1.FuncinstInterface, funcinst *rfv1beta3.Funci
 		// 	klog.V(4).Infof("no changes %s", msg(t, i))
 		// 	return t, nil
 		// }
-		if t.Status.Active == status.Active && reflect.DeepEqual(t.Status.Conditions, status.Conditions) {
+		if t.Status.Active == status.Active && t.Status.Provisioned == status.Provisioned && reflect.DeepEqual(t.Status.Conditions, status.Conditions) {
 			klog.V(4).Infof("no changes %s", msg(t, i))
 			return t, nil
 		}